@@ -0,0 +1,216 @@
+package ais
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// NetworkOptions configures an opt-aiNet immune network (de Castro & Timmis)
+type NetworkOptions struct {
+	InitialCells int     // cells created by Init, at least 1
+	Clones       int     // clones of each cell every step
+	Beta         float64 // mutation of a clone is a fraction (1/Beta) * exp(-normalised revenue) of each good's bound range
+	// Suppression removes cells within this distance of a better cell,
+	// distance is the root mean square difference of prices as fractions of each good's bound range
+	Suppression float64
+	// Stability is the relative change in average revenue between steps under which the network is
+	// considered stable, and is suppressed before new cells are added
+	Stability float64
+	NewCells  float64 // fraction of the network size added as random cells after suppression
+}
+
+// DefaultNetworkOptions returns opt-aiNet parameters suited to the pricing problem
+func DefaultNetworkOptions() NetworkOptions {
+	return NetworkOptions{
+		InitialCells: 20,
+		Clones:       10,
+		Beta:         10,
+		Suppression:  0.25,
+		Stability:    0.001,
+		NewCells:     0.4,
+	}
+}
+
+// Network is an opt-aiNet immune network
+// unlike ImmuneSystem, the number of cells changes as similar cells are suppressed and random cells are added,
+// so the surviving cells are diverse high revenue solutions, see Memory
+type Network struct {
+	Cells          []TCell
+	BestCell       TCell
+	Suppressions   int // number of times the network has stabilised and been suppressed
+	opts           NetworkOptions
+	problem        pp.Problem
+	averageRevenue float64
+}
+
+// NewNetwork creates a new immune network of random cells
+// if pr is nil, the network is left empty until Init is called
+func NewNetwork(opts NetworkOptions, pr pp.Problem) *Network {
+	n := new(Network)
+	n.opts = opts
+	if pr != nil {
+		n.Init(pr)
+	}
+	return n
+}
+
+// Init populates the network with new random cells for problem pr, discarding any previous progress
+func (n *Network) Init(pr pp.Problem) {
+	n.problem = pr
+	n.Cells = []TCell{}
+	n.BestCell = TCell{}
+	n.Suppressions = 0
+	n.averageRevenue = 0
+	cells := n.opts.InitialCells
+	if cells < 1 {
+		cells = 1 // cloning needs a cell to start from
+	}
+	n.addRandomCells(cells)
+}
+
+// Best returns a copy of the prices of the best cell, and its revenue
+func (n *Network) Best() ([]float64, float64) {
+	return append([]float64{}, n.BestCell.prices...), n.BestCell.Revenue
+}
+
+// Memory returns the cells of the network sorted by the highest revenue first
+// after suppression, no two cells are within the Suppression distance of each other
+func (n *Network) Memory() []TCell {
+	memory := sortPopulation(n.Cells)
+	for i := range memory {
+		memory[i].prices = append([]float64{}, memory[i].prices...)
+	}
+	return memory
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (n *Network) Params() map[string]string {
+	return map[string]string{
+		"initialCells": strconv.Itoa(n.opts.InitialCells),
+		"clones":       strconv.Itoa(n.opts.Clones),
+		"beta":         strconv.FormatFloat(n.opts.Beta, 'f', -1, 64),
+		"suppression":  strconv.FormatFloat(n.opts.Suppression, 'f', -1, 64),
+		"stability":    strconv.FormatFloat(n.opts.Stability, 'f', -1, 64),
+		"newCells":     strconv.FormatFloat(n.opts.NewCells, 'f', -1, 64),
+	}
+}
+
+// Step clones and mutates every cell, keeping the best of each cell and its clones
+// once the average revenue stabilises, similar cells are suppressed and random cells are added
+func (n *Network) Step() {
+	n.cloneAndMutate()
+
+	var total float64
+	for _, c := range n.Cells {
+		total += c.Revenue
+	}
+	average := total / float64(len(n.Cells))
+	stable := n.averageRevenue != 0 && math.Abs(average-n.averageRevenue) <= n.opts.Stability*math.Abs(n.averageRevenue)
+	n.averageRevenue = average
+	if !stable {
+		return
+	}
+
+	n.suppress()
+	n.Suppressions++
+	n.addRandomCells(int(math.Ceil(n.opts.NewCells * float64(len(n.Cells)))))
+	n.averageRevenue = 0 // new cells unsettle the network, wait for it to stabilise again
+}
+
+// cloneAndMutate replaces each cell with the best of itself and its mutated clones
+// mutation is Gaussian, smaller for cells with a higher revenue relative to the rest of the network
+func (n *Network) cloneAndMutate() {
+	lowest, highest := n.Cells[0].Revenue, n.Cells[0].Revenue
+	for _, c := range n.Cells {
+		lowest = math.Min(lowest, c.Revenue)
+		highest = math.Max(highest, c.Revenue)
+	}
+
+	bounds := n.problem.Bounds()
+	for i, cell := range n.Cells {
+		normalised := 1.0
+		if highest > lowest {
+			normalised = (cell.Revenue - lowest) / (highest - lowest)
+		}
+		alpha := math.Exp(-normalised) / n.opts.Beta
+
+		best := cell
+		for j := 0; j < n.opts.Clones; j++ {
+			prices := make([]float64, len(cell.prices))
+			for k := range prices {
+				prices[k] = cell.prices[k] + alpha*(bounds[k][1]-bounds[k][0])*rand.NormFloat64()
+				prices[k] = math.Min(math.Max(prices[k], bounds[k][0]), bounds[k][1])
+			}
+			if !n.problem.IsValid(prices) {
+				continue
+			}
+			rev, _ := n.problem.Evaluate(prices)
+			if rev > best.Revenue {
+				best = TCell{prices: prices, Revenue: rev}
+			}
+		}
+		n.Cells[i] = best
+		if best.Revenue > n.BestCell.Revenue {
+			n.BestCell = best
+		}
+	}
+}
+
+// suppress removes every cell within the Suppression distance of a cell with a higher revenue
+// the best cell is never suppressed, so at least one cell is kept
+func (n *Network) suppress() {
+	sorted := sortPopulation(n.Cells)
+	survivors := []TCell{}
+	for _, c := range sorted {
+		similar := false
+		for _, s := range survivors {
+			if n.distance(c.prices, s.prices) < n.opts.Suppression {
+				similar = true
+				break
+			}
+		}
+		if !similar {
+			survivors = append(survivors, c)
+		}
+	}
+	if len(survivors) > 0 {
+		n.Cells = survivors
+	}
+}
+
+// distance is the root mean square difference between a and b, as fractions of each good's bound range
+func (n *Network) distance(a, b []float64) float64 {
+	bounds := n.problem.Bounds()
+	var sum float64
+	for i := range a {
+		d := (a[i] - b[i]) / (bounds[i][1] - bounds[i][0])
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(a)))
+}
+
+// addRandomCells adds count new random cells to the network
+func (n *Network) addRandomCells(count int) {
+	numGoods := len(n.problem.Bounds())
+	for i := 0; i < count; i++ {
+		prices := make([]float64, numGoods)
+		for !n.problem.IsValid(prices) {
+			for j := 0; j < numGoods; j++ {
+				prices[j] = n.problem.Bounds()[j][0] + rand.Float64()*(n.problem.Bounds()[j][1]-n.problem.Bounds()[j][0])
+			}
+		}
+		rev, _ := n.problem.Evaluate(prices)
+		n.Cells = append(n.Cells, TCell{prices: prices, Revenue: rev})
+		if len(n.Cells) == 1 || rev > n.BestCell.Revenue {
+			n.BestCell = n.Cells[len(n.Cells)-1]
+		}
+	}
+}
+
+// Prices returns a copy of the prices of the cell
+func (c TCell) Prices() []float64 {
+	return append([]float64{}, c.prices...)
+}
@@ -4,6 +4,7 @@ import (
 	"math"
 	"math/rand"
 	"sort"
+	"strconv"
 
 	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
 )
@@ -12,35 +13,53 @@ import (
 type TCell struct {
 	prices  []float64
 	Revenue float64
+	age     int // steps survived in the population
 }
 
 // ImmuneSystem is an object containing cells and parameter values
 type ImmuneSystem struct {
-	Cells                        []TCell
-	BestCell                     TCell
-	replacement, cloneSizeFactor int
-	problem                      *pp.PricingProblem
-	NormalisedRevenue            float64
+	Cells             []TCell
+	BestCell          TCell
+	numPopulation     int
+	opts              Options
+	problem           pp.Problem
+	NormalisedRevenue float64
+	MutationRates     []float64   // mean mutation rate of the clones of each step
+	NumClones         int         // clones created in the last step
+	Diversity         []Diversity // diversity of the population before and after replacement in each step
+	upperBound        float64     // running estimate of the highest revenue, for UpperBoundNormalisation
 }
 
-const bestFitness = 6000.0
-
 // NewImmuneSystem generates a new population of cells (prices and revenue)
-func NewImmuneSystem(numGoods, numPopulation, replacement, cloneSizeFactor int, pr *pp.PricingProblem) *ImmuneSystem {
-	// define and populate new immune system
+// clones are changed by opts.Mutation, or Inversion (the coursework's contiguous hypermutation) if it is nil
+// if pr is nil, the immune system is left empty until Init is called
+func NewImmuneSystem(numGoods, numPopulation int, opts Options, pr pp.Problem) *ImmuneSystem {
+	// define new immune system
 	is := new(ImmuneSystem)
+	is.numPopulation = numPopulation
+	is.opts = opts
+	if opts.Mutation == nil {
+		is.opts.Mutation = Inversion{}
+	}
+	if pr != nil {
+		is.Init(pr)
+	}
+	return is
+}
+
+// Init populates the immune system with new random cells for problem pr, discarding any previous progress
+func (is *ImmuneSystem) Init(pr pp.Problem) {
 	is.problem = pr
-	is.replacement = replacement
-	is.cloneSizeFactor = cloneSizeFactor
+	numGoods := len(pr.Bounds())
 
 	// create population of cells
-	population := make([]TCell, numPopulation)
+	population := make([]TCell, is.numPopulation)
 	var totalFitness float64
 	bestCell := TCell{}
 
-	for i := 0; i < numPopulation; i++ {
-		prices, rev := is.randomPrices(numGoods) // get random prices and revenue
-		population[i] = TCell{prices, rev}       // assign prices and revenue to a cell, add to population
+	for i := 0; i < is.numPopulation; i++ {
+		prices, rev := is.randomPrices(numGoods)            // get random prices and revenue
+		population[i] = TCell{prices: prices, Revenue: rev} // assign prices and revenue to a cell, add to population
 		if i == 0 || bestCell.Revenue < rev {
 			bestCell = population[i] // keep track of best cell revenue
 		}
@@ -49,11 +68,45 @@ func NewImmuneSystem(numGoods, numPopulation, replacement, cloneSizeFactor int,
 	is.Cells = population
 	is.BestCell = bestCell
 	is.NormalisedRevenue = bestCell.Revenue / totalFitness
-	return is
+	is.MutationRates = []float64{}
+	is.Diversity = []Diversity{}
+	is.upperBound = 0
+}
+
+// Options returns the parameters of the immune system
+func (is *ImmuneSystem) Options() Options {
+	return is.opts
+}
+
+// Params lists the parameters of the immune system, for recording alongside results
+func (is *ImmuneSystem) Params() map[string]string {
+	params := is.opts.Params()
+	params["population"] = strconv.Itoa(is.numPopulation)
+	return params
+}
+
+// Best returns a copy of the prices of the best cell, and its revenue
+func (is *ImmuneSystem) Best() ([]float64, float64) {
+	return append([]float64{}, is.BestCell.prices...), is.BestCell.Revenue
+}
+
+// Inject replaces the worst cell with a new cell of prices with the given revenue
+// used to feed prices found by another search, such as a local search, back into the population
+func (is *ImmuneSystem) Inject(prices []float64, revenue float64) {
+	worst := 0
+	for i := range is.Cells {
+		if is.Cells[i].Revenue < is.Cells[worst].Revenue {
+			worst = i
+		}
+	}
+	is.Cells[worst] = TCell{prices: append([]float64{}, prices...), Revenue: revenue}
+	if revenue > is.BestCell.Revenue {
+		is.BestCell = is.Cells[worst]
+	}
 }
 
-// Update acts as a step, and causes alterations on the population
-func (is *ImmuneSystem) Update() {
+// Step acts as a step, and causes alterations on the population
+func (is *ImmuneSystem) Step() {
 	is.Cells = is.metaDynamics(is.clonalSelection())
 	var totalFitness float64
 	for i := 0; i < len(is.Cells); i++ {
@@ -65,29 +118,39 @@ func (is *ImmuneSystem) Update() {
 	is.NormalisedRevenue = is.BestCell.Revenue / totalFitness
 }
 
-// clonalSelection creates clones and mutates at random, given bestFitness constant
+// clonalSelection creates clones and mutates at random, at a rate given by the normalised revenue of each clone
 func (is *ImmuneSystem) clonalSelection() []TCell {
 	// create clones
 	clones := [][]TCell{}
-	numCopies := len(is.Cells) * is.cloneSizeFactor
-	for i := 0; i < len(is.Cells); i++ {
-		clonesOfIndex := make([]TCell, numCopies)
-		for j := 0; j < numCopies; j++ {
-			clonesOfIndex[j] = is.Cells[i]
-			copy(clonesOfIndex[j].prices, is.Cells[i].prices) // deep copy array otherwise both will change
+	selected, counts := is.cloneCounts()
+	is.NumClones = 0
+	for i := 0; i < len(selected); i++ {
+		clonesOfIndex := make([]TCell, counts[i])
+		for j := 0; j < counts[i]; j++ {
+			clonesOfIndex[j] = selected[i]
+			copy(clonesOfIndex[j].prices, selected[i].prices) // deep copy array otherwise both will change
 		}
 		clones = append(clones, clonesOfIndex)
+		is.NumClones += counts[i]
 	}
 
 	// mutation
+	n := newNormaliser(is.opts, is.Cells, is.BestCell.Revenue, &is.upperBound)
+	var totalRate float64
+	var numClones int
 	for i := 0; i < len(clones); i++ {
 		for j := 0; j < len(clones[i]); j++ {
-			mutationRate := math.Exp(-1 * clones[i][j].Revenue / bestFitness)
+			mutationRate := n.rate(clones[i][j].Revenue)
+			totalRate += mutationRate
+			numClones++
 			if rand.Float64() <= mutationRate {
-				clones[i][j] = is.contiguousHyperMutation(clones[i][j].prices) // cant change
+				clones[i][j] = is.mutate(clones[i][j])
 			}
 		}
 	}
+	if numClones > 0 {
+		is.MutationRates = append(is.MutationRates, totalRate/float64(numClones))
+	}
 
 	// prepare for use in main population
 	returnedClones := []TCell{}
@@ -97,42 +160,44 @@ func (is *ImmuneSystem) clonalSelection() []TCell {
 	return returnedClones
 }
 
-// contiguousHyperMutation is the process of mutating any clones that are randomly selected
-func (is *ImmuneSystem) contiguousHyperMutation(prices []float64) TCell {
-	newPrices := []float64{}
-	var hotspotA, hotspotB int
+// cloneCounts returns the cells to clone, and the number of clones of each
+func (is *ImmuneSystem) cloneCounts() ([]TCell, []int) {
+	n := len(is.Cells)
+	if is.opts.Cloning != RankCloning {
+		counts := make([]int, n)
+		for i := range counts {
+			counts[i] = n * is.opts.CloneSizeFactor
+		}
+		return is.Cells, counts
+	}
 
-	// select two hotspots within the array
-	for hotspotA == hotspotB {
-		hotspotA = rand.Intn(len(prices) - 2)
-		hotspotA = rand.Intn(len(prices) - 1)
+	selected := int(math.Ceil(is.opts.SelectionFraction * float64(n)))
+	if selected < 1 {
+		selected = 1
+	} else if selected > n {
+		selected = n
 	}
-	if hotspotA > hotspotB {
-		hotspotA, hotspotB = hotspotB, hotspotA
+	counts := make([]int, selected)
+	for rank := 1; rank <= selected; rank++ {
+		counts[rank-1] = int(math.Max(math.Round(is.opts.Beta*float64(n)/float64(rank)), 1))
 	}
+	return sortPopulation(is.Cells)[:selected], counts
+}
 
-	// add prices to newPrices, and when between hotspots in reverse order
-	for i := 0; i < len(prices); i++ {
-		if i < hotspotB && i > hotspotA {
-			for i := hotspotB; i > hotspotA; i-- {
-				newPrices = append(newPrices, prices[i])
-				if i == hotspotA {
-					i++
-				}
-			}
-		} else {
-			newPrices = append(newPrices, prices[i])
-		}
-	}
-	for i := hotspotB; i > hotspotA; i-- {
-		newPrices = append(newPrices, prices[i])
+// mutate changes the prices of a clone with the mutation operator
+// the clone is returned unchanged if the mutated prices are not valid
+func (is *ImmuneSystem) mutate(clone TCell) TCell {
+	newPrices := is.opts.Mutation.Mutate(clone.prices, is.problem.Bounds())
+	if !is.problem.IsValid(newPrices) {
+		return clone
 	}
 	rev, _ := is.problem.Evaluate(newPrices)
-	return TCell{newPrices, rev}
+	return TCell{prices: newPrices, Revenue: rev}
 }
 
 // metaDynamics combines and sorts the clones and original population, and sorts by revenue
 // then cuts down the population, down to the size of the original population
+// and replaces cells according to the replacement policy
 func (is *ImmuneSystem) metaDynamics(clones []TCell) []TCell {
 	// combine population and clones
 	newPopulation := []TCell{}
@@ -141,21 +206,24 @@ func (is *ImmuneSystem) metaDynamics(clones []TCell) []TCell {
 	newPopulation = sortPopulation(newPopulation)
 
 	newPopulation = newPopulation[:len(is.Cells)] // cuts population down to original population size
-
-	//replace with random solutions
-	for i := len(is.Cells) - is.replacement - 1; i < len(newPopulation); i++ {
-		rp, rev := is.randomPrices(len(is.Cells[0].prices))
-		newPopulation[i] = TCell{rp, rev}
+	for i := range newPopulation {
+		newPopulation[i].age++
 	}
+
+	//replace with new solutions
+	d := Diversity{Before: is.diversity(newPopulation)}
+	is.replace(newPopulation)
+	d.After = is.diversity(newPopulation)
+	is.Diversity = append(is.Diversity, d)
 	return newPopulation
 }
 
-// randomPrices generates random prices that evaluated as valid by the PricingProblem
+// randomPrices generates random prices within the bounds of the problem
 func (is *ImmuneSystem) randomPrices(numGoods int) ([]float64, float64) {
 	prices := make([]float64, numGoods)
 	for !is.problem.IsValid(prices) { // while not valid, select prices at random
 		for i := 0; i < numGoods; i++ {
-			prices[i] = rand.Float64() * 10
+			prices[i] = is.problem.Bounds()[i][0] + rand.Float64()*(is.problem.Bounds()[i][1]-is.problem.Bounds()[i][0])
 		}
 	}
 	rev, _ := is.problem.Evaluate(prices)
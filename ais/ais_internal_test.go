@@ -0,0 +1,224 @@
+package ais
+
+import (
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_mutationOperators(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(20, 0, false)
+	opts := DefaultOptions()
+	opts.Replacement, opts.CloneSizeFactor = 2, 1
+	is := NewImmuneSystem(20, 10, opts, pr)
+
+	operators := []MutationOperator{
+		Gaussian{Sigma: 0.1},
+		UniformReset{Rate: 0.1},
+		Polynomial{Eta: 20, Rate: 0.1},
+		BlockResample{},
+		Inversion{},
+	}
+	for _, op := range operators {
+		for _, cell := range is.Cells {
+			before := append([]float64{}, cell.prices...)
+			mutated := op.Mutate(cell.prices, pr.Bounds())
+			if !pr.IsValid(mutated) {
+				t.Errorf("%v mutation produced invalid prices : %v", op, mutated)
+			}
+			changed := false
+			for i := range mutated {
+				if mutated[i] != before[i] {
+					changed = true
+				}
+				if cell.prices[i] != before[i] {
+					t.Errorf("%v mutation changed the original prices", op)
+					break
+				}
+			}
+			if !changed {
+				t.Errorf("%v mutation did not change prices : %v", op, mutated)
+			}
+		}
+	}
+}
+
+func Test_inversion(t *testing.T) {
+	prices := []float64{1, 2, 3, 4, 5}
+	bounds := [][]float64{{0, 10}, {0, 10}, {0, 10}, {0, 10}, {0, 10}}
+	for n := 0; n < 50; n++ {
+		mutated := Inversion{}.Mutate(prices, bounds)
+		if len(mutated) != len(prices) {
+			t.Fatalf("inversion changed the number of prices : %v", mutated)
+		}
+		// the reversed block starts and ends where prices differ, everything between is reversed
+		a, b := -1, -1
+		for i := range prices {
+			if mutated[i] != prices[i] {
+				if a == -1 {
+					a = i
+				}
+				b = i
+			}
+		}
+		if a == -1 || a == b {
+			t.Fatalf("inversion did not reverse a block : %v", mutated)
+		}
+		for i := a; i <= b; i++ {
+			if mutated[i] != prices[a+b-i] {
+				t.Errorf("inversion did not reverse between %v and %v : %v", a, b, mutated)
+			}
+		}
+	}
+}
+
+func Test_cloneCounts(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(20, 0, false)
+	opts := DefaultOptions()
+	opts.Replacement = 2
+	is := NewImmuneSystem(20, 10, opts, pr)
+
+	cells, counts := is.cloneCounts()
+	if len(cells) != 10 || counts[0] != 80 || counts[9] != 80 {
+		t.Errorf("uniform cloning not 80 clones of 10 cells : %v %v", len(cells), counts)
+	}
+
+	is.opts.Cloning, is.opts.Beta, is.opts.SelectionFraction = RankCloning, 1, 0.5
+	cells, counts = is.cloneCounts()
+	expected := []int{10, 5, 3, 3, 2}
+	if len(cells) != len(expected) {
+		t.Fatalf("rank cloning selected %v cells, expected %v", len(cells), len(expected))
+	}
+	for i := range expected {
+		if counts[i] != expected[i] {
+			t.Errorf("rank cloning counts not as expected : %v, expected %v", counts, expected)
+			break
+		}
+		if i > 0 && cells[i].Revenue > cells[i-1].Revenue {
+			t.Errorf("rank cloning cells not sorted by revenue")
+		}
+	}
+}
+
+func Test_replacementIndices(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(2, 0, false)
+	opts := DefaultOptions()
+	opts.Replacement = 2
+	is := NewImmuneSystem(2, 5, opts, pr)
+
+	population := []TCell{
+		{prices: []float64{1, 1}, Revenue: 50, age: 1},
+		{prices: []float64{2, 2}, Revenue: 40, age: 5},
+		{prices: []float64{1, 1}, Revenue: 30, age: 1},
+		{prices: []float64{3, 3}, Revenue: 20, age: 2},
+		{prices: []float64{4, 4}, Revenue: 10, age: 1},
+	}
+	expected := map[ReplacementPolicy][]int{
+		WorstReplacement:     {4, 3},
+		AgeReplacement:       {1, 3},
+		DuplicateReplacement: {2, 4},
+	}
+	for policy, indices := range expected {
+		is.opts.Policy = policy
+		chosen := is.replacementIndices(population, 2)
+		if len(chosen) != 2 || chosen[0] != indices[0] || chosen[1] != indices[1] {
+			t.Errorf("%v replacement chose %v, expected %v", policy, chosen, indices)
+		}
+	}
+}
+
+func Test_networkInitialCells(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	for _, cells := range []int{-1, 0, 1, 3} {
+		opts := DefaultNetworkOptions()
+		opts.InitialCells = cells
+		n := NewNetwork(opts, pr)
+		expected := cells
+		if expected < 1 {
+			expected = 1
+		}
+		if len(n.Cells) != expected {
+			t.Errorf("%v initial cells : network has %v cells, expected %v", cells, len(n.Cells), expected)
+		}
+		for i := 0; i < 50; i++ {
+			n.Step()
+		}
+		if _, rev := n.Best(); len(n.Cells) == 0 || rev <= 0 {
+			t.Errorf("%v initial cells : %v cells with best revenue %v", cells, len(n.Cells), rev)
+		}
+	}
+}
+
+func Test_networkSuppression(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(2, 0, false)
+	n := NewNetwork(DefaultNetworkOptions(), pr)
+	n.Cells = []TCell{
+		{prices: []float64{1, 1}, Revenue: 10},
+		{prices: []float64{1.5, 1.5}, Revenue: 20}, // within 0.25 of the first
+		{prices: []float64{9, 9}, Revenue: 5},
+	}
+	n.suppress()
+	if len(n.Cells) != 2 || n.Cells[0].Revenue != 20 || n.Cells[1].Revenue != 5 {
+		t.Errorf("expected the better of the similar cells and the distant cell to survive : %v", n.Cells)
+	}
+
+	// identical cells suppress all but one
+	n.Cells = []TCell{{prices: []float64{2, 2}, Revenue: 1}, {prices: []float64{2, 2}, Revenue: 1}, {prices: []float64{2, 2}, Revenue: 1}}
+	n.suppress()
+	if len(n.Cells) != 1 {
+		t.Errorf("expected one cell to survive, actual %v", len(n.Cells))
+	}
+}
+
+func Test_networkGrowth(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	opts := DefaultNetworkOptions()
+	opts.Suppression = 0 // no cell is suppressed
+	opts.Stability = 1e9 // every change in average revenue counts as stable
+	n := NewNetwork(opts, pr)
+
+	// the first step sets the average revenue, the next suppresses and adds 40% more cells, the next settles again
+	for i, expected := range []int{20, 28, 28, 40} {
+		n.Step()
+		if len(n.Cells) != expected {
+			t.Errorf("step %v : expected %v cells, actual %v", i+1, expected, len(n.Cells))
+		}
+	}
+	if n.Suppressions != 2 {
+		t.Errorf("expected 2 suppressions, actual %v", n.Suppressions)
+	}
+}
+
+func Test_networkMemory(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	n := NewNetwork(DefaultNetworkOptions(), pr)
+	for i := 0; i < 200; i++ {
+		n.Step()
+	}
+	n.suppress()
+	memory := n.Memory()
+	if len(memory) < 2 || memory[0].Revenue != n.BestCell.Revenue {
+		t.Fatalf("expected several cells led by the best, actual %v cells", len(memory))
+	}
+	for i := range memory {
+		for j := i + 1; j < len(memory); j++ {
+			if memory[j].Revenue > memory[i].Revenue {
+				t.Errorf("memory not sorted by revenue")
+			}
+			if d := n.distance(memory[i].prices, memory[j].prices); d < n.opts.Suppression {
+				t.Errorf("cells %v and %v are %v apart, within the suppression distance", i, j, d)
+			}
+		}
+	}
+	memory[0].prices[0] = -1
+	if n.Memory()[0].prices[0] == -1 {
+		t.Errorf("memory shares prices with the network")
+	}
+}
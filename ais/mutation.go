@@ -0,0 +1,133 @@
+package ais
+
+import (
+	"math"
+	"math/rand"
+)
+
+// MutationOperator changes the prices of a clone
+// Mutate must return a new slice of prices within bounds, leaving prices unchanged
+type MutationOperator interface {
+	Mutate(prices []float64, bounds [][]float64) []float64
+}
+
+// Gaussian adds normally distributed noise to every price
+type Gaussian struct {
+	Sigma float64 // standard deviation, as a fraction of each good's bound range
+}
+
+// UniformReset redraws prices uniformly within their bounds
+type UniformReset struct {
+	Rate float64 // probability of each price being redrawn, at least one price is always redrawn
+}
+
+// Polynomial is Deb's polynomial mutation, perturbing prices by a polynomial distribution within their bounds
+type Polynomial struct {
+	Eta  float64 // distribution index, higher values make smaller perturbations more likely
+	Rate float64 // probability of each price being perturbed, at least one price is always perturbed
+}
+
+// BlockResample redraws every price between two random hotspots uniformly within their bounds
+type BlockResample struct{}
+
+// Inversion reverses the order of the prices between two random hotspots, the coursework's contiguous hypermutation
+// it never creates a new price value, only moves existing ones
+type Inversion struct{}
+
+func (g Gaussian) String() string      { return "gaussian" }
+func (u UniformReset) String() string  { return "uniform reset" }
+func (p Polynomial) String() string    { return "polynomial" }
+func (b BlockResample) String() string { return "block resample" }
+func (i Inversion) String() string     { return "inversion" }
+
+// Mutate adds noise to every price, clamping to the bounds
+func (g Gaussian) Mutate(prices []float64, bounds [][]float64) []float64 {
+	newPrices := make([]float64, len(prices))
+	for i := 0; i < len(prices); i++ {
+		newPrices[i] = clamp(prices[i]+rand.NormFloat64()*g.Sigma*(bounds[i][1]-bounds[i][0]), bounds[i])
+	}
+	return newPrices
+}
+
+// Mutate redraws each price with probability Rate
+func (u UniformReset) Mutate(prices []float64, bounds [][]float64) []float64 {
+	newPrices := append([]float64{}, prices...)
+	for _, i := range chosenPrices(len(prices), u.Rate) {
+		newPrices[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+	}
+	return newPrices
+}
+
+// Mutate perturbs each price with probability Rate
+func (p Polynomial) Mutate(prices []float64, bounds [][]float64) []float64 {
+	newPrices := append([]float64{}, prices...)
+	for _, i := range chosenPrices(len(prices), p.Rate) {
+		width := bounds[i][1] - bounds[i][0]
+		lower, upper := (prices[i]-bounds[i][0])/width, (bounds[i][1]-prices[i])/width
+		r := rand.Float64()
+		power := 1 / (p.Eta + 1)
+		var delta float64
+		if r < 0.5 {
+			delta = math.Pow(2*r+(1-2*r)*math.Pow(1-lower, p.Eta+1), power) - 1
+		} else {
+			delta = 1 - math.Pow(2*(1-r)+2*(r-0.5)*math.Pow(1-upper, p.Eta+1), power)
+		}
+		newPrices[i] = clamp(prices[i]+delta*width, bounds[i])
+	}
+	return newPrices
+}
+
+// Mutate redraws the prices between two hotspots
+func (b BlockResample) Mutate(prices []float64, bounds [][]float64) []float64 {
+	newPrices := append([]float64{}, prices...)
+	hotspotA, hotspotB := hotspots(len(prices))
+	for i := hotspotA; i <= hotspotB; i++ {
+		newPrices[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+	}
+	return newPrices
+}
+
+// Mutate reverses the prices between two hotspots, clamping any price moved outside its good's bounds
+func (in Inversion) Mutate(prices []float64, bounds [][]float64) []float64 {
+	newPrices := append([]float64{}, prices...)
+	hotspotA, hotspotB := hotspots(len(prices))
+	for i := hotspotA; i <= hotspotB; i++ {
+		newPrices[i] = clamp(prices[hotspotA+hotspotB-i], bounds[i])
+	}
+	return newPrices
+}
+
+// hotspots selects two different indices of n prices, lowest first
+// both are 0 when there are fewer than 2 prices
+func hotspots(n int) (int, int) {
+	if n < 2 {
+		return 0, 0
+	}
+	hotspotA, hotspotB := rand.Intn(n), rand.Intn(n)
+	for hotspotA == hotspotB {
+		hotspotB = rand.Intn(n)
+	}
+	if hotspotA > hotspotB {
+		hotspotA, hotspotB = hotspotB, hotspotA
+	}
+	return hotspotA, hotspotB
+}
+
+// chosenPrices selects each of n prices with probability rate, always selecting at least one
+func chosenPrices(n int, rate float64) []int {
+	chosen := []int{}
+	for i := 0; i < n; i++ {
+		if rand.Float64() < rate {
+			chosen = append(chosen, i)
+		}
+	}
+	if len(chosen) == 0 && n > 0 {
+		chosen = append(chosen, rand.Intn(n))
+	}
+	return chosen
+}
+
+// clamp limits price to within bound
+func clamp(price float64, bound []float64) float64 {
+	return math.Min(math.Max(price, bound[0]), bound[1])
+}
@@ -0,0 +1,151 @@
+package ais
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Normalisation determines the revenue that cell revenues are compared against to set their mutation rate
+// mutation rate = exp(-normalised revenue), so better cells are mutated less often
+type Normalisation int
+
+const (
+	// FixedNormalisation divides revenue by the constant BestFitness, as in the coursework
+	FixedNormalisation Normalisation = iota
+	// BestNormalisation divides revenue by the revenue of the current best cell
+	BestNormalisation
+	// MinMaxNormalisation scales revenue between the worst (0) and best (1) cells of the population
+	MinMaxNormalisation
+	// UpperBoundNormalisation divides revenue by a running estimate of the highest attainable revenue,
+	// the highest seen of the best revenue plus its lead over the mean revenue of the population
+	UpperBoundNormalisation
+)
+
+// Normalisations lists every mutation rate normalisation, for comparisons
+var Normalisations = []Normalisation{FixedNormalisation, BestNormalisation, MinMaxNormalisation, UpperBoundNormalisation}
+
+func (n Normalisation) String() string {
+	switch n {
+	case FixedNormalisation:
+		return "fixed"
+	case BestNormalisation:
+		return "best"
+	case MinMaxNormalisation:
+		return "min-max"
+	case UpperBoundNormalisation:
+		return "upper bound"
+	}
+	return "unknown"
+}
+
+// Cloning determines how many clones each cell receives every step
+type Cloning int
+
+const (
+	// UniformCloning gives every cell len(Cells) * CloneSizeFactor clones, as in the coursework
+	UniformCloning Cloning = iota
+	// RankCloning is CLONALG selection: the best SelectionFraction of cells are cloned,
+	// and the cell ranked r (best is 1) receives round(Beta * len(Cells) / r) clones
+	RankCloning
+)
+
+func (c Cloning) String() string {
+	switch c {
+	case UniformCloning:
+		return "uniform"
+	case RankCloning:
+		return "rank"
+	}
+	return "unknown"
+}
+
+// Options configures an ImmuneSystem
+type Options struct {
+	Replacement     int // cells replaced each step, the best cell is never replaced
+	Policy          ReplacementPolicy
+	Cloning         Cloning
+	CloneSizeFactor int     // clones of each cell per cell in the population, under UniformCloning
+	Beta            float64 // multiplier of the clones of each cell under RankCloning
+	// SelectionFraction is the fraction of the best cells that are cloned under RankCloning, at least one cell is cloned
+	SelectionFraction float64
+	Mutation          MutationOperator // changes the prices of clones, nil uses Inversion
+	Normalisation     Normalisation
+	BestFitness       float64 // revenue dividing cell revenues under FixedNormalisation
+}
+
+// DefaultOptions returns the parameters used in the coursework
+func DefaultOptions() Options {
+	return Options{
+		Replacement:       10,
+		Policy:            WorstReplacement,
+		Cloning:           UniformCloning,
+		CloneSizeFactor:   8,
+		Beta:              1,
+		SelectionFraction: 0.5,
+		Mutation:          Inversion{},
+		Normalisation:     FixedNormalisation,
+		BestFitness:       6000.0,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"replacement":   strconv.Itoa(o.Replacement),
+		"policy":        o.Policy.String(),
+		"cloning":       o.Cloning.String(),
+		"mutation":      "inversion",
+		"normalisation": o.Normalisation.String(),
+	}
+	if s, ok := o.Mutation.(fmt.Stringer); ok {
+		params["mutation"] = s.String()
+	}
+	if o.Cloning == RankCloning {
+		params["beta"] = strconv.FormatFloat(o.Beta, 'f', -1, 64)
+		params["selectionFraction"] = strconv.FormatFloat(o.SelectionFraction, 'f', -1, 64)
+	} else {
+		params["cloneSizeFactor"] = strconv.Itoa(o.CloneSizeFactor)
+	}
+	if o.Normalisation == FixedNormalisation {
+		params["bestFitness"] = strconv.FormatFloat(o.BestFitness, 'f', -1, 64)
+	}
+	return params
+}
+
+// normaliser converts revenues to mutation rates for one step of the immune system
+type normaliser struct {
+	scale, offset float64 // normalised revenue = (revenue - offset) / scale
+}
+
+// rate returns the mutation rate of a cell with revenue rev
+func (n normaliser) rate(rev float64) float64 {
+	if n.scale <= 0 {
+		return 1.0
+	}
+	return math.Exp(-(rev - n.offset) / n.scale)
+}
+
+// newNormaliser creates the normaliser for the current population
+// upperBound is the running estimate of UpperBoundNormalisation, updated in place
+func newNormaliser(o Options, cells []TCell, best float64, upperBound *float64) normaliser {
+	switch o.Normalisation {
+	case BestNormalisation:
+		return normaliser{scale: best}
+	case MinMaxNormalisation:
+		lowest := best
+		for _, c := range cells {
+			lowest = math.Min(lowest, c.Revenue)
+		}
+		return normaliser{scale: best - lowest, offset: lowest}
+	case UpperBoundNormalisation:
+		var mean float64
+		for _, c := range cells {
+			mean += c.Revenue
+		}
+		mean /= float64(len(cells))
+		*upperBound = math.Max(*upperBound, best+(best-mean))
+		return normaliser{scale: *upperBound}
+	}
+	return normaliser{scale: o.BestFitness}
+}
@@ -0,0 +1,150 @@
+package ais
+
+import (
+	"math"
+	"sort"
+)
+
+// ReplacementPolicy determines which cells are replaced each step, and what replaces them
+type ReplacementPolicy int
+
+const (
+	// WorstReplacement replaces the Replacement cells with the lowest revenue with random cells
+	WorstReplacement ReplacementPolicy = iota
+	// AgeReplacement replaces the Replacement cells that have survived the most steps with random cells
+	AgeReplacement
+	// DuplicateReplacement replaces cells with the same prices as a better cell first, then the lowest revenue cells
+	DuplicateReplacement
+	// OppositionReplacement replaces the lowest revenue cells with the better of a random cell and the opposite
+	// of the cell being replaced (each price reflected about the middle of its bounds)
+	OppositionReplacement
+)
+
+// ReplacementPolicies lists every replacement policy, for comparisons
+var ReplacementPolicies = []ReplacementPolicy{WorstReplacement, AgeReplacement, DuplicateReplacement, OppositionReplacement}
+
+func (r ReplacementPolicy) String() string {
+	switch r {
+	case WorstReplacement:
+		return "worst"
+	case AgeReplacement:
+		return "age"
+	case DuplicateReplacement:
+		return "duplicates"
+	case OppositionReplacement:
+		return "opposition"
+	}
+	return "unknown"
+}
+
+// Diversity is the diversity of the population either side of replacement in one step
+type Diversity struct {
+	Before, After float64
+}
+
+// replace replaces cells of the population, which is sorted by the highest revenue first
+// the best cell is never replaced
+func (is *ImmuneSystem) replace(population []TCell) {
+	k := is.opts.Replacement
+	if k > len(population)-1 {
+		k = len(population) - 1
+	}
+	if k <= 0 {
+		return
+	}
+
+	for _, i := range is.replacementIndices(population, k) {
+		rp, rev := is.randomPrices(len(population[i].prices))
+		if is.opts.Policy == OppositionReplacement {
+			op := is.opposite(population[i].prices)
+			if opRev, _ := is.problem.Evaluate(op); opRev > rev {
+				rp, rev = op, opRev
+			}
+		}
+		population[i] = TCell{prices: rp, Revenue: rev}
+	}
+}
+
+// replacementIndices chooses the indices of the k cells to replace according to the replacement policy
+func (is *ImmuneSystem) replacementIndices(population []TCell, k int) []int {
+	n := len(population)
+	indices := []int{}
+	switch is.opts.Policy {
+	case AgeReplacement:
+		candidates := make([]int, n-1)
+		for i := range candidates {
+			candidates[i] = n - 1 - i // worst first, so the worst of equally old cells are replaced
+		}
+		sort.SliceStable(candidates, func(a, b int) bool {
+			return population[candidates[a]].age > population[candidates[b]].age
+		})
+		return candidates[:k]
+	case DuplicateReplacement:
+		for i := 1; i < n && len(indices) < k; i++ {
+			for j := 0; j < i; j++ {
+				if samePrices(population[i].prices, population[j].prices) {
+					indices = append(indices, i)
+					break
+				}
+			}
+		}
+	}
+
+	// fill with the worst cells not already chosen
+	for i := n - 1; i > 0 && len(indices) < k; i-- {
+		chosen := false
+		for _, index := range indices {
+			if index == i {
+				chosen = true
+				break
+			}
+		}
+		if !chosen {
+			indices = append(indices, i)
+		}
+	}
+	return indices
+}
+
+// opposite reflects each price about the middle of its bounds
+func (is *ImmuneSystem) opposite(prices []float64) []float64 {
+	bounds := is.problem.Bounds()
+	op := make([]float64, len(prices))
+	for i := range prices {
+		op[i] = bounds[i][0] + bounds[i][1] - prices[i]
+	}
+	return op
+}
+
+// diversity is the root mean square distance of cells from the centre of the population,
+// with prices as fractions of each good's bound range
+func (is *ImmuneSystem) diversity(population []TCell) float64 {
+	if len(population) == 0 {
+		return 0
+	}
+	bounds := is.problem.Bounds()
+	centre := make([]float64, len(bounds))
+	for _, c := range population {
+		for i := range c.prices {
+			centre[i] += c.prices[i] / float64(len(population))
+		}
+	}
+	var sum float64
+	for _, c := range population {
+		for i := range c.prices {
+			d := (c.prices[i] - centre[i]) / (bounds[i][1] - bounds[i][0])
+			sum += d * d
+		}
+	}
+	return math.Sqrt(sum / float64(len(population)*len(bounds)))
+}
+
+// samePrices checks whether two cells have identical prices
+func samePrices(a, b []float64) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
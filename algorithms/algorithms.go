@@ -1,16 +1,26 @@
 package algorithms
 
 import (
+	"context"
 	"fmt"
-	"log"
-	"math/rand"
 	"time"
 
 	"github.com/aagoldingay/ci-cw-go/ais"
+	"github.com/aagoldingay/ci-cw-go/bayesopt"
+	"github.com/aagoldingay/ci-cw-go/cmaes"
+	"github.com/aagoldingay/ci-cw-go/de"
+	"github.com/aagoldingay/ci-cw-go/ga"
+	"github.com/aagoldingay/ci-cw-go/localsearch"
 	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
 	"github.com/aagoldingay/ci-cw-go/pso"
+	"github.com/aagoldingay/ci-cw-go/tabu"
 )
 
+// DefaultStop is the stopping criterion used in the coursework, a 3 second time limit
+// every search returns promptly with the best result so far when its context is cancelled,
+// and a nil stop criterion runs until the deadline of the context, or DefaultStop when it has none
+var DefaultStop = TimeLimit(3 * time.Second)
+
 // Revenue is a struct acting as a payload to access prices and revenues
 // intended to store best revenue
 type Revenue struct {
@@ -20,120 +30,184 @@ type Revenue struct {
 
 // AISSearch is a CI algorithm approach to finding the highest possible revenue
 // clones and mutates a population using elitism to generate better solutions
-func AISSearch(numGoods, numPopulation, replacement, cloneSizeFactor int, trace bool, p *pp.PricingProblem) (float64, []float64) {
-	revenueTrack := []float64{}
-	population := ais.NewImmuneSystem(numGoods, numPopulation, replacement, cloneSizeFactor, p)
-	fmt.Printf("Cells created...\n")
-	//fmt.Printf("best cell: %v\n", population.BestCell)
-
-	timeout := time.After(3 * time.Second)
-	tick := time.Tick(5 * time.Millisecond)
-
-	for { //for i := 0; i < 100; i++ {
-		// if testing with steps, comment switch statement, move inner case <-timeout out of for loop
-
-		select {
-		// timeout reached, stop running
-		case <-timeout:
-			fmt.Printf("Final best revenue : %v\n", population.BestCell)
-			revenueTrack = append(revenueTrack, population.BestCell.Revenue) // adds 30th result
-			return population.BestCell.Revenue, revenueTrack
-		// tick reached, record data
-		case <-tick:
-			if trace {
-				revenueTrack = append(revenueTrack, population.BestCell.Revenue)
-			}
-		// run procedure
-		default:
-			population.Update()
-			//fmt.Printf("[%v] best cell : %v\n", i+1, population.BestCell) //uncomment on iterations
-		}
+// opts determines how cells are cloned, mutated and replaced
+func AISSearch(ctx context.Context, numGoods, numPopulation int, opts ais.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	population := ais.NewImmuneSystem(numGoods, numPopulation, opts, nil)
+	fmt.Printf("Cells created (%v mutation)...\n", population.Options().Mutation)
+	result := Run(ctx, population, p, stop, trace)
+	report(result)
+	if rates := population.MutationRates; len(rates) > 0 {
+		fmt.Printf("Mutation rate (%v normalisation) : first %.3f, last %.3f\n", opts.Normalisation, rates[0], rates[len(rates)-1])
+	}
+	fmt.Printf("Clones per step (%v cloning) : %v\n", opts.Cloning, population.NumClones)
+	if d := population.Diversity; len(d) > 0 {
+		fmt.Printf("Diversity (%v replacement) : before %.3f, after %.3f\n", opts.Policy, d[len(d)-1].Before, d[len(d)-1].After)
+	}
+	return result.Revenue, result.Trace
+}
+
+// AINetSearch is the opt-aiNet immune network approach to finding the highest possible revenue
+// suppresses similar cells so the network holds several diverse high revenue solutions, which are printed and returned
+func AINetSearch(ctx context.Context, opts ais.NetworkOptions, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64, []ais.TCell) {
+	network := ais.NewNetwork(opts, nil)
+	fmt.Printf("Network created...\n")
+	result := Run(ctx, network, p, stop, trace)
+	report(result)
+	memory := network.Memory()
+	fmt.Printf("Network cells : %v, suppressions : %v\n", len(memory), network.Suppressions)
+	for i := 0; i < len(memory) && i < 5; i++ {
+		fmt.Printf("  %.2f : %.2f\n", memory[i].Revenue, memory[i].Prices())
 	}
+	return result.Revenue, result.Trace, memory
 }
 
 // PSOSearch is a CI algorithm approach to finding the highest possible revenue
 // uses 'particles' to traverse the problem like a map, potentially encountering new, better results
-func PSOSearch(numGoods, numParticles int, trace bool, p *pp.PricingProblem) (float64, []float64) {
-	revenueTrack := []float64{}
-	swarm := pso.NewSwarm(numGoods, numParticles, p)
+// opts determines how particles move, including how particles leaving the problem bounds are repaired
+func PSOSearch(ctx context.Context, numGoods, numParticles int, opts pso.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	swarm := pso.NewSwarm(numGoods, numParticles, opts, nil)
 	fmt.Printf("Particles created...\n")
-	//fmt.Printf("Best : %v | %v\n", swarm.BestPrices, swarm.BestRevenue)
-
-	timeout := time.After(3 * time.Second)
-	tick := time.Tick(5 * time.Millisecond)
-
-	for { //for i := 0; i < 100; i++ {
-		// if testing with steps, comment switch statement, move inner case <-timeout out of for loop
-
-		select {
-		// timeout reached, stop running
-		case <-timeout:
-			fmt.Printf("Final best revenue : %v\n", swarm.BestRevenue)
-			revenueTrack = append(revenueTrack, swarm.BestRevenue) // adds 30th result
-			return swarm.BestRevenue, revenueTrack
-		// tick reached, record data
-		case <-tick:
-			if trace {
-				revenueTrack = append(revenueTrack, swarm.BestRevenue)
-			}
-		// run procedure
-		default:
-			swarm.Update()
-			//fmt.Printf("[%v] new best: prices : %v | revenue : %v\n", i+1, swarm.BestPrices, swarm.BestRevenue) // uncomment if steps		}
-		}
+	result := Run(ctx, swarm, p, stop, trace)
+	report(result)
+	fmt.Printf("Boundary hits (%v/%v) : %v\n", opts.Boundary, opts.Velocity, swarm.BoundaryHits())
+	fmt.Printf("Final velocity magnitude : %+v, peak %v\n", swarm.Velocity, swarm.PeakVelocity)
+	return result.Revenue, result.Trace
+}
+
+// CMAESSearch is a covariance matrix adaptation evolution strategy approach to finding the highest possible revenue
+// samples prices from a multivariate normal distribution, adapting its mean, covariance and step size towards the best samples
+// opts determines the starting step size and population size, and how the search restarts once converged
+func CMAESSearch(ctx context.Context, numGoods int, opts cmaes.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	es := cmaes.NewES(numGoods, opts, nil)
+	fmt.Printf("Distribution created...\n")
+	result := Run(ctx, es, p, stop, trace)
+	report(result)
+	fmt.Printf("Restarts (%v) : %v, final population size : %v\n", opts.Restart, es.Restarts, es.Lambda)
+	return result.Revenue, result.Trace
+}
+
+// DESearch is a differential evolution approach to finding the highest possible revenue
+// each individual is challenged by a trial made from the differences between other individuals, keeping the better
+// opts determines the mutation strategy, and its differential weight (F) and crossover probability (CR)
+func DESearch(ctx context.Context, numGoods, numIndividuals int, opts de.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	population := de.NewPopulation(numGoods, numIndividuals, opts, nil)
+	fmt.Printf("Individuals created (%v)...\n", opts.Strategy)
+	result := Run(ctx, population, p, stop, trace)
+	report(result)
+	if a := population.Adaptations; len(a) > 0 {
+		fmt.Printf("Adapted means : F %.3f, CR %.3f\n", a[len(a)-1].F, a[len(a)-1].CR)
 	}
+	return result.Revenue, result.Trace
 }
 
-// RandomSearch is a heuristic method of attempting to find the highest possible revenue
-// Approach : Create an array of random prices len(numGoods) and compare against the current best Revenue
-// (This method was translated from the provided Java code)
-func RandomSearch(numGoods int, trace bool, p *pp.PricingProblem) (float64, []float64) {
-	revenueTrack := []float64{}
-	prices := make([]float64, numGoods)
-	newPrices := make([]float64, numGoods)
+// GASearch is a real-coded genetic algorithm approach to finding the highest possible revenue
+// each generation keeps the best individuals and breeds the rest from selected parents by crossover and mutation
+func GASearch(ctx context.Context, numGoods, numIndividuals int, opts ga.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	population := ga.NewPopulation(numGoods, numIndividuals, opts, nil)
+	fmt.Printf("Individuals created (%v selection, %v crossover)...\n", opts.Selection, opts.Crossover)
+	result := Run(ctx, population, p, stop, trace)
+	report(result)
+	return result.Revenue, result.Trace
+}
 
-	for i := 0; i < numGoods; i++ {
-		prices[i] = rand.Float64() * 10
+// AnnealingSearch is a simulated annealing approach to finding the highest possible revenue
+// moves to neighbouring prices, accepting worse neighbours with a probability that falls as the temperature cools
+// opts determines the neighbourhood move and the cooling schedule
+func AnnealingSearch(ctx context.Context, opts localsearch.AnnealOptions, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	sa := localsearch.NewAnnealer(opts, nil)
+	fmt.Printf("Annealer created (%v cooling)...\n", opts.Cooling)
+	result := Run(ctx, sa, p, stop, trace)
+	report(result)
+	fmt.Printf("Temperature : initial %.4g, final %.4g\n", sa.Initial, sa.Temperature)
+	if rates := sa.AcceptanceRates; len(rates) > 0 {
+		fmt.Printf("Acceptance rate : first %.3f, last %.3f\n", rates[0], rates[len(rates)-1])
 	}
+	return result.Revenue, result.Trace
+}
+
+// HillClimbSearch is a stochastic hill climbing approach to finding the highest possible revenue
+// samples neighbouring prices each step, moving only to better neighbours
+// opts determines the neighbourhood move and whether the first or best improving neighbour is taken
+func HillClimbSearch(ctx context.Context, opts localsearch.ClimbOptions, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	hc := localsearch.NewHillClimber(opts, nil)
+	fmt.Printf("Climber created (%v improvement)...\n", opts.Improvement)
+	result := Run(ctx, hc, p, stop, trace)
+	report(result)
+	fmt.Printf("Improving steps : %v of %v\n", hc.Improvements, result.Steps)
+	return result.Revenue, result.Trace
+}
 
-	bRevenue, err := p.Evaluate(prices)
-	bestRevenue := Revenue{prices, bRevenue}
-	if err != nil {
-		log.Fatal(err)
+// CoordinateAscentSearch is a coordinate ascent approach to finding the highest possible revenue
+// maximises the revenue over one good's price at a time, with the other prices fixed, cycling through the goods
+// opts determines the line search, and whether the search restarts from random prices once at a coordinate-wise optimum
+func CoordinateAscentSearch(ctx context.Context, opts localsearch.CoordinateOptions, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	ca := localsearch.NewCoordinateAscent(opts, nil)
+	fmt.Printf("Coordinate ascent created (%v line search)...\n", opts.LineSearch)
+	result := Run(ctx, ca, p, stop, trace)
+	report(result)
+	if optima := ca.Optima; len(optima) > 0 {
+		fmt.Printf("Coordinate-wise optima reached : %v, first %.2f after %v cycles\n", len(optima), optima[0], ca.FirstOptimum)
+	} else {
+		fmt.Printf("No coordinate-wise optimum reached after %v cycles\n", ca.Cycles)
 	}
+	return result.Revenue, result.Trace
+}
+
+// BayesSearch is a Bayesian optimisation approach to finding the highest possible revenue, for expensive revenues
+// fits a Gaussian process to every revenue evaluated, and evaluates the prices that maximise an acquisition function
+// the run ends once opts.Budget revenues have been evaluated, when stop is met or when ctx is done,
+// a nil stop relying on the budget alone
+func BayesSearch(ctx context.Context, opts bayesopt.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	bo := bayesopt.NewOptimiser(opts, nil)
+	fmt.Printf("Surrogate created (%v kernel, %v acquisition)...\n", opts.Kernel, opts.Acquisition)
+	// invalid prices count against the budget without calling Evaluate, so the optimiser reports when it is spent
+	budget := exhaustedCriterion{bo}
+	if stop == nil {
+		stop = budget
+	} else {
+		stop = Any(stop, budget)
+	}
+	result := Run(ctx, bo, p, stop, trace)
+	report(result)
+	fmt.Printf("Fitted surrogate : %v\n", bo.Surrogate())
+	return result.Revenue, result.Trace
+}
+
+// exhaustedCriterion stops a Bayesian optimisation run once its budget of evaluations has been spent
+type exhaustedCriterion struct {
+	bo *bayesopt.Optimiser
+}
+
+func (c exhaustedCriterion) Met(s RunState) (bool, string) {
+	return c.bo.Exhausted, fmt.Sprintf("budget of %v evaluations", c.bo.Options().Budget)
+}
+
+// TabuSearch is a tabu search approach to finding the highest possible revenue, on a ladder of prices for each good
+// moves each step to the best neighbouring prices which are not tabu, even when worse, remembering recent moves
+// opts determines the ladders, what a move makes tabu and for how long, and when to diversify
+func TabuSearch(ctx context.Context, opts tabu.Options, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	ts := tabu.NewSearch(opts, nil)
+	fmt.Printf("Tabu search created (%v tabu, tenure %v)...\n", opts.Attribute, opts.Tenure)
+	result := Run(ctx, ts, p, stop, trace)
+	report(result)
+	fmt.Printf("Aspirations : %v, diversifications : %v\n", ts.Aspirations, ts.Diversifications)
+	return result.Revenue, result.Trace
+}
+
+// RandomSearch is a heuristic method of attempting to find the highest possible revenue
+// Approach : Create an array of random prices, one for each good, and compare against the current best Revenue
+// (This method was translated from the provided Java code)
+func RandomSearch(ctx context.Context, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	result := Run(ctx, &RandomSearcher{}, p, stop, trace)
+	report(result)
+	return result.Revenue, result.Trace
+}
 
-	timeout := time.After(3 * time.Second)
-	tick := time.Tick(5 * time.Millisecond)
-
-	for { //for i := 0; i < 100; i++ {
-		// if testing with steps, comment switch statement, move inner case <-timeout out of for loop
-
-		select {
-		// timeout reached, stop running
-		case <-timeout:
-			fmt.Printf("Final best revenue : %v\n", bestRevenue)
-			revenueTrack = append(revenueTrack, bestRevenue.revenue) // adds 30th result
-			return bestRevenue.revenue, revenueTrack
-		// tick reached, record data
-		case <-tick:
-			if trace {
-				revenueTrack = append(revenueTrack, bestRevenue.revenue)
-			}
-		// run procedure
-		default:
-			for j := 0; j < numGoods; j++ {
-				newPrices[j] = rand.Float64() * 10
-			}
-
-			newRevenue, err := p.Evaluate(newPrices)
-			if err != nil {
-				log.Fatal(err)
-			}
-			if newRevenue > bestRevenue.revenue {
-				copy(bestRevenue.prices, newPrices)
-				bestRevenue.revenue = newRevenue
-				//fmt.Printf("New best revenue : %v \n", bestRevenue)
-			}
-		}
+// report prints the parameters and outcome of a run
+func report(result Result) {
+	if result.Params != nil {
+		fmt.Printf("Parameters : %v\n", result.Params)
 	}
+	fmt.Printf("Final best revenue : %v\n", result.Revenue)
+	fmt.Printf("Stopped by %v after %v steps, %v evaluations\n", result.StoppedBy, result.Steps, result.Evaluations)
 }
@@ -0,0 +1,372 @@
+package algorithms
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/aagoldingay/ci-cw-go/ais"
+	"github.com/aagoldingay/ci-cw-go/bayesopt"
+	"github.com/aagoldingay/ci-cw-go/localsearch"
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+	"github.com/aagoldingay/ci-cw-go/pso"
+)
+
+// flatProblem earns a revenue of 1 for any prices of its one good
+type flatProblem struct{}
+
+func (flatProblem) Evaluate(prices []float64) (float64, error) { return 1, nil }
+func (flatProblem) Bounds() [][]float64                        { return [][]float64{{0, 1}} }
+func (flatProblem) IsValid(prices []float64) bool              { return true }
+
+// stubOptimizer reports revenues[i] as its best revenue after i steps, the last once past the end,
+// making one evaluation in Init and evaluations in each step
+type stubOptimizer struct {
+	revenues    []float64
+	evaluations int
+	delay       time.Duration   // slept each step
+	onStep      func(steps int) // called at the end of each step
+	steps       int
+	problem     pp.Problem
+}
+
+func (s *stubOptimizer) Init(pr pp.Problem) {
+	s.problem, s.steps = pr, 0
+	s.problem.Evaluate([]float64{0})
+}
+
+func (s *stubOptimizer) Step() {
+	s.steps++
+	time.Sleep(s.delay)
+	for i := 0; i < s.evaluations; i++ {
+		s.problem.Evaluate([]float64{0})
+	}
+	if s.onStep != nil {
+		s.onStep(s.steps)
+	}
+}
+
+func (s *stubOptimizer) Best() ([]float64, float64) {
+	i := s.steps
+	if i >= len(s.revenues) {
+		i = len(s.revenues) - 1
+	}
+	return []float64{float64(s.steps)}, s.revenues[i]
+}
+
+// parameterisedStub is a stubOptimizer which reports its parameters
+type parameterisedStub struct {
+	*stubOptimizer
+}
+
+func (parameterisedStub) Params() map[string]string {
+	return map[string]string{"name": "stub"}
+}
+
+func Test_runResult(t *testing.T) {
+	stub := &stubOptimizer{revenues: []float64{1, 2, 3, 4, 5, 6}, evaluations: 3}
+	result := Run(context.Background(), stub, flatProblem{}, MaxSteps(4), false)
+	expected := Result{
+		Prices:      []float64{4},
+		Revenue:     5,
+		Trace:       []float64{5},
+		Steps:       4,
+		Evaluations: 13, // 1 in Init, 3 each step
+		Elapsed:     result.Elapsed,
+		StoppedBy:   "4 steps",
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %+v, actual %+v", expected, result)
+	}
+
+	result = Run(context.Background(), parameterisedStub{stub}, flatProblem{}, MaxSteps(1), false)
+	if !reflect.DeepEqual(result.Params, map[string]string{"name": "stub"}) {
+		t.Errorf("unexpected parameters %v", result.Params)
+	}
+	if result.Steps != 1 || result.Evaluations != 4 {
+		t.Errorf("Init did not restart the run : %v steps, %v evaluations", result.Steps, result.Evaluations)
+	}
+}
+
+func Test_runTrace(t *testing.T) {
+	revenues := make([]float64, 100)
+	for i := range revenues {
+		revenues[i] = float64(i)
+	}
+	stub := &stubOptimizer{revenues: revenues, delay: time.Millisecond}
+	result := Run(context.Background(), stub, flatProblem{}, TimeLimit(50*time.Millisecond), true)
+	if len(result.Trace) < 3 {
+		t.Fatalf("expected a revenue every %v, actual trace %v", traceInterval, result.Trace)
+	}
+	for i := 1; i < len(result.Trace); i++ {
+		if result.Trace[i] < result.Trace[i-1] {
+			t.Errorf("best revenue fell in trace %v", result.Trace)
+		}
+	}
+	if final := result.Trace[len(result.Trace)-1]; final != result.Revenue {
+		t.Errorf("trace ends with %v, not the final revenue %v", final, result.Revenue)
+	}
+	if result.Elapsed < 50*time.Millisecond || result.StoppedBy != "time limit 50ms" {
+		t.Errorf("stopped by %q after %v", result.StoppedBy, result.Elapsed)
+	}
+}
+
+func Test_countingProblem(t *testing.T) {
+	counter := &countingProblem{Problem: flatProblem{}}
+	for i := 0; i < 3; i++ {
+		if rev, err := counter.Evaluate([]float64{0.5}); rev != 1 || err != nil {
+			t.Errorf("unexpected revenue %v, error %v", rev, err)
+		}
+	}
+	if counter.evaluations != 3 || len(counter.Bounds()) != 1 || !counter.IsValid([]float64{0.5}) {
+		t.Errorf("counted %v evaluations, or the problem was not passed through", counter.evaluations)
+	}
+}
+
+func Test_stopCriteria(t *testing.T) {
+	// each criterion sees the same run, checked after each state
+	states := []RunState{
+		{Elapsed: 0, Steps: 0, Evaluations: 10, Revenue: 100, Stagnant: 0},
+		{Elapsed: time.Second, Steps: 1, Evaluations: 20, Revenue: 150, Stagnant: 0},
+		{Elapsed: 2 * time.Second, Steps: 2, Evaluations: 30, Revenue: 150, Stagnant: 1},
+		{Elapsed: 3 * time.Second, Steps: 3, Evaluations: 40, Revenue: 150, Stagnant: 2},
+		{Elapsed: 4 * time.Second, Steps: 4, Evaluations: 50, Revenue: 200, Stagnant: 0},
+	}
+	tests := []struct {
+		criterion StopCriterion
+		met       []bool
+		reason    string
+	}{
+		{TimeLimit(3 * time.Second), []bool{false, false, false, true, true}, "time limit 3s"},
+		{MaxSteps(2), []bool{false, false, true, true, true}, "2 steps"},
+		{MaxEvaluations(25), []bool{false, false, true, true, true}, "25 evaluations"},
+		{TargetRevenue(150), []bool{false, true, true, true, true}, "target revenue 150"},
+		{NoImprovement(2), []bool{false, false, false, true, false}, "no improvement for 2 steps"},
+		{Any(MaxSteps(10), TargetRevenue(150)), []bool{false, true, true, true, true}, "target revenue 150"},
+		{Any(MaxSteps(3), TargetRevenue(1000)), []bool{false, false, false, true, true}, "3 steps"},
+		{All(MaxSteps(2), NoImprovement(1)), []bool{false, false, true, true, false}, "2 steps and no improvement for 1 steps"},
+		{Any(), []bool{false, false, false, false, false}, ""},
+		{All(), []bool{false, false, false, false, false}, ""},
+	}
+	for _, tt := range tests {
+		for i, s := range states {
+			met, reason := tt.criterion.Met(s)
+			if met != tt.met[i] {
+				t.Errorf("%v : expected met %v at state %v", tt.reason, tt.met[i], i)
+			}
+			if met && reason != tt.reason {
+				t.Errorf("expected reason %q, actual %q", tt.reason, reason)
+			}
+		}
+	}
+}
+
+func Test_runStopsWithoutImprovement(t *testing.T) {
+	// improves for 3 steps, then stagnates
+	stub := &stubOptimizer{revenues: []float64{1, 2, 3, 4}}
+	result := Run(context.Background(), stub, flatProblem{}, Any(NoImprovement(5), MaxSteps(100)), false)
+	if result.Steps != 8 || result.Revenue != 4 || result.StoppedBy != "no improvement for 5 steps" {
+		t.Errorf("stopped by %q after %v steps with revenue %v", result.StoppedBy, result.Steps, result.Revenue)
+	}
+}
+
+func Test_runCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stub := &stubOptimizer{revenues: []float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}}
+	stub.onStep = func(steps int) {
+		if steps == 5 {
+			cancel()
+		}
+	}
+	result := Run(ctx, stub, flatProblem{}, MaxSteps(1000), true)
+	if result.StoppedBy != context.Canceled.Error() {
+		t.Errorf("stopped by %q, expected the context error", result.StoppedBy)
+	}
+	if result.Steps != 5 || result.Revenue != 6 || !reflect.DeepEqual(result.Prices, []float64{5}) {
+		t.Errorf("expected the best after 5 steps, actual %v after %v steps", result.Revenue, result.Steps)
+	}
+	if final := result.Trace[len(result.Trace)-1]; final != 6 {
+		t.Errorf("trace ends with %v, not the best so far", final)
+	}
+}
+
+func Test_runDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	revenues := make([]float64, 1000)
+	for i := range revenues {
+		revenues[i] = float64(i)
+	}
+	stub := &stubOptimizer{revenues: revenues, delay: time.Millisecond}
+	result := Run(ctx, stub, flatProblem{}, nil, false)
+	if result.StoppedBy != context.DeadlineExceeded.Error() {
+		t.Errorf("stopped by %q, expected the context error", result.StoppedBy)
+	}
+	if result.Steps == 0 || result.Revenue != float64(result.Steps) || result.Prices[0] != float64(result.Steps) {
+		t.Errorf("expected the best after %v steps, actual %v", result.Steps, result.Revenue)
+	}
+	if result.Elapsed < 25*time.Millisecond || result.Elapsed > time.Second {
+		t.Errorf("ran for %v, expected until the deadline", result.Elapsed)
+	}
+}
+
+// slopeProblem earns the price of its one good, within bounds [0, 1]
+type slopeProblem struct{}
+
+func (slopeProblem) Evaluate(prices []float64) (float64, error) { return prices[0], nil }
+func (slopeProblem) Bounds() [][]float64                        { return [][]float64{{0, 1}} }
+func (slopeProblem) IsValid(prices []float64) bool              { return prices[0] >= 0 && prices[0] <= 1 }
+
+// stubPopulation holds prices which only change when injected
+type stubPopulation struct {
+	prices   []float64
+	revenue  float64
+	injected int
+}
+
+func (s *stubPopulation) Init(pr pp.Problem) {
+	s.prices, s.revenue, s.injected = []float64{0.5}, 0.5, 0
+}
+func (s *stubPopulation) Step() {}
+func (s *stubPopulation) Best() ([]float64, float64) {
+	return append([]float64{}, s.prices...), s.revenue
+}
+func (s *stubPopulation) Inject(prices []float64, revenue float64) {
+	s.prices, s.revenue = prices, revenue
+	s.injected++
+}
+
+func Test_memeticPolishes(t *testing.T) {
+	population := &stubPopulation{}
+	m := NewMemetic(population, MemeticOptions{Polisher: localsearch.CoordinateSearch{Step: 0.1}, Every: 3, Budget: 50})
+	for run := 0; run < 2; run++ {
+		m.Init(slopeProblem{})
+		for i := 0; i < 10; i++ {
+			m.Step()
+		}
+		// polished after steps 3, 6 and 9, only the first finding better prices
+		if m.Polishes != 3 || !reflect.DeepEqual(m.Improvements, []float64{0.5, 0, 0}) || population.injected != 1 {
+			t.Errorf("run %v : %v polishes, improvements %v, %v injections", run, m.Polishes, m.Improvements, population.injected)
+		}
+		if prices, rev := m.Best(); prices[0] != 1 || rev != 1 {
+			t.Errorf("run %v : best %v with revenue %v, expected the polished prices", run, prices, rev)
+		}
+		if total, improved := m.Improvement(); total != 0.5 || improved != 1 {
+			t.Errorf("run %v : improvement %v over %v polishes", run, total, improved)
+		}
+	}
+
+	// never polishes without a positive interval
+	m = NewMemetic(population, MemeticOptions{Polisher: localsearch.CoordinateSearch{Step: 0.1}, Every: 0, Budget: 50})
+	m.Init(slopeProblem{})
+	for i := 0; i < 10; i++ {
+		m.Step()
+	}
+	if m.Polishes != 0 || len(m.Improvements) != 0 || population.injected != 0 {
+		t.Errorf("polished %v times with no interval", m.Polishes)
+	}
+}
+
+func Test_memeticPopulations(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	for _, population := range []Injectable{
+		pso.NewSwarm(5, 10, pso.DefaultOptions(), nil),
+		ais.NewImmuneSystem(5, 10, ais.DefaultOptions(), nil),
+	} {
+		m := NewMemetic(population, MemeticOptions{Polisher: localsearch.PatternSearch{Step: 0.05}, Every: 2, Budget: 200})
+		m.Init(pr)
+		before := 0.0
+		for i := 0; i < 10; i++ {
+			m.Step()
+			_, rev := m.Best()
+			if rev < before {
+				t.Fatalf("%T : best fell from %v to %v", population, before, rev)
+			}
+			if i%2 == 1 && m.Improvements[len(m.Improvements)-1] > 0 {
+				// polished prices were injected, so the population's best includes them
+				prices, _ := m.Best()
+				if check, _ := pr.Evaluate(prices); check != rev {
+					t.Errorf("%T : best revenue %v, prices earn %v", population, rev, check)
+				}
+			}
+			before = rev
+		}
+		if total, improved := m.Improvement(); m.Polishes != 5 || improved == 0 || total <= 0 {
+			t.Errorf("%T : %v polishes improved the best %v times by %v", population, m.Polishes, improved, total)
+		}
+	}
+}
+
+// cancellingProblem cancels its context once it has made a number of evaluations
+type cancellingProblem struct {
+	pp.Problem
+	after, evaluations int
+	cancel             context.CancelFunc
+}
+
+func (c *cancellingProblem) Evaluate(prices []float64) (float64, error) {
+	c.evaluations++
+	if c.evaluations == c.after {
+		c.cancel()
+	}
+	return c.Problem.Evaluate(prices)
+}
+
+func Test_compareCancelled(t *testing.T) {
+	opts := pso.DefaultOptions()
+	perRun := Run(context.Background(), pso.NewSwarm(1, 5, opts, nil), flatProblem{}, MaxSteps(3), false).Evaluations
+
+	compare := map[string]func(ctx context.Context, p pp.Problem) []Summary{
+		"topologies": func(ctx context.Context, p pp.Problem) []Summary {
+			return CompareTopologies(ctx, 5, opts, []pso.Topology{pso.GlobalTopology, pso.RingTopology}, 4, MaxSteps(3), p)
+		},
+		"variants": func(ctx context.Context, p pp.Problem) []Summary {
+			return CompareVariants(ctx, 5, opts, []pso.Variant{pso.Canonical, pso.BareBones}, 4, MaxSteps(3), p)
+		},
+	}
+	for name, c := range compare {
+		// cancelled halfway through the third run of the first configuration
+		ctx, cancel := context.WithCancel(context.Background())
+		summaries := c(ctx, &cancellingProblem{Problem: flatProblem{}, after: 2*perRun + perRun/2, cancel: cancel})
+		cancel()
+		if summaries[0].Runs != 2 || summaries[0].Mean != 1 || summaries[0].Worst != 1 || summaries[0].StdDev != 0 {
+			t.Errorf("%v : expected 2 completed runs earning 1, actual %v", name, summaries[0])
+		}
+		if summaries[1].Runs != 0 {
+			t.Errorf("%v : expected no runs after cancelling, actual %v", name, summaries[1])
+		}
+
+		summaries = c(context.Background(), flatProblem{})
+		if summaries[0].Runs != 4 || summaries[1].Runs != 4 {
+			t.Errorf("%v : expected 4 runs of each, actual %v", name, summaries)
+		}
+	}
+}
+
+// halfValidProblem is a slopeProblem whose prices above 0.5 are invalid
+type halfValidProblem struct {
+	slopeProblem
+}
+
+func (halfValidProblem) IsValid(prices []float64) bool { return prices[0] >= 0 && prices[0] <= 0.5 }
+
+func Test_bayesSearchBudget(t *testing.T) {
+	// invalid prices spend the budget without being evaluated, yet the run still ends with it
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	opts := bayesopt.DefaultOptions()
+	opts.Budget, opts.Initial, opts.Samples = 12, 6, 50
+	rev, _ := BayesSearch(ctx, opts, nil, false, halfValidProblem{})
+	if ctx.Err() != nil || rev > 0.5 {
+		t.Errorf("run ended by %v with revenue %v", ctx.Err(), rev)
+	}
+
+	opts.Budget = 0
+	if rev, _ := BayesSearch(ctx, opts, nil, false, slopeProblem{}); ctx.Err() != nil || !math.IsInf(rev, -1) {
+		t.Errorf("run without a budget evaluated %v, or did not end", rev)
+	}
+}
@@ -0,0 +1,97 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+	"github.com/aagoldingay/ci-cw-go/pso"
+)
+
+// Summary aggregates the final revenues of repeated runs of one configuration
+type Summary struct {
+	Name                      string
+	Runs                      int
+	Mean, StdDev, Best, Worst float64
+}
+
+func (s Summary) String() string {
+	return fmt.Sprintf("%-12v runs %v | mean %.2f | sd %.2f | best %.2f | worst %.2f", s.Name, s.Runs, s.Mean, s.StdDev, s.Best, s.Worst)
+}
+
+// summarise calculates the summary statistics of the final revenues of runs
+func summarise(name string, revenues []float64) Summary {
+	s := Summary{Name: name, Runs: len(revenues)}
+	if len(revenues) == 0 {
+		return s
+	}
+	s.Best, s.Worst = revenues[0], revenues[0]
+	for _, r := range revenues {
+		s.Mean += r
+		s.Best = math.Max(s.Best, r)
+		s.Worst = math.Min(s.Worst, r)
+	}
+	s.Mean /= float64(len(revenues))
+	for _, r := range revenues {
+		s.StdDev += (r - s.Mean) * (r - s.Mean)
+	}
+	s.StdDev = math.Sqrt(s.StdDev / float64(len(revenues)))
+	return s
+}
+
+// repeat calls run up to runs times, returning the final revenue of each run that completed
+// a run cut short by cancelling ctx is left out, and no more are started
+func repeat(ctx context.Context, runs int, run func(r int) Result) []float64 {
+	revenues := []float64{}
+	for r := 0; r < runs && ctx.Err() == nil; r++ {
+		result := run(r)
+		if ctx.Err() != nil {
+			break
+		}
+		revenues = append(revenues, result.Revenue)
+	}
+	return revenues
+}
+
+// CompareTopologies runs PSO runs times with each neighbourhood topology on problem p
+// all other parameters are taken from opts, the summary of each topology is printed and returned
+// if ctx is cancelled, each summary covers only the runs completed before it
+func CompareTopologies(ctx context.Context, numParticles int, opts pso.Options, topologies []pso.Topology, runs int, stop StopCriterion, p pp.Problem) []Summary {
+	summaries := make([]Summary, len(topologies))
+	for i, topology := range topologies {
+		opts.Topology = topology
+		revenues := repeat(ctx, runs, func(r int) Result {
+			fmt.Printf("----------\nPSO (%v) run %v\n----------\n", topology, r+1)
+			return Run(ctx, pso.NewSwarm(len(p.Bounds()), numParticles, opts, nil), p, stop, false)
+		})
+		summaries[i] = summarise(topology.String(), revenues)
+	}
+
+	fmt.Printf("----------\nTopology comparison\n----------\n")
+	for _, s := range summaries {
+		fmt.Println(s)
+	}
+	return summaries
+}
+
+// CompareVariants runs PSO runs times with each particle movement rule on problem p
+// all other parameters are taken from opts, the summary of each variant is printed and returned
+// if ctx is cancelled, each summary covers only the runs completed before it
+func CompareVariants(ctx context.Context, numParticles int, opts pso.Options, variants []pso.Variant, runs int, stop StopCriterion, p pp.Problem) []Summary {
+	summaries := make([]Summary, len(variants))
+	for i, variant := range variants {
+		opts.Variant = variant
+		revenues := repeat(ctx, runs, func(r int) Result {
+			fmt.Printf("----------\nPSO (%v) run %v\n----------\n", variant, r+1)
+			return Run(ctx, pso.NewSwarm(len(p.Bounds()), numParticles, opts, nil), p, stop, false)
+		})
+		summaries[i] = summarise(variant.String(), revenues)
+	}
+
+	fmt.Printf("----------\nVariant comparison\n----------\n")
+	for _, s := range summaries {
+		fmt.Println(s)
+	}
+	return summaries
+}
@@ -0,0 +1,138 @@
+package algorithms
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/aagoldingay/ci-cw-go/ais"
+	"github.com/aagoldingay/ci-cw-go/localsearch"
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+	"github.com/aagoldingay/ci-cw-go/pso"
+)
+
+// Injectable is an Optimizer whose population can take in prices found by another search
+// pso.Swarm and ais.ImmuneSystem satisfy Injectable
+type Injectable interface {
+	Optimizer
+	// Inject replaces a member of the population with prices and their revenue
+	Inject(prices []float64, revenue float64)
+}
+
+// compile time checks that each population satisfies Injectable
+var (
+	_ Injectable = (*pso.Swarm)(nil)
+	_ Injectable = (*ais.ImmuneSystem)(nil)
+)
+
+// MemeticOptions configures a Memetic search
+type MemeticOptions struct {
+	Polisher localsearch.Polisher
+	Every    int // steps of the population between polishes
+	Budget   int // evaluations spent by each polish
+}
+
+// DefaultMemeticOptions returns memetic parameters suited to the pricing problem, polishing with pattern search
+func DefaultMemeticOptions() MemeticOptions {
+	return MemeticOptions{
+		Polisher: localsearch.PatternSearch{Step: 0.05},
+		Every:    5,
+		Budget:   2000,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o MemeticOptions) Params() map[string]string {
+	return map[string]string{
+		"polisher": fmt.Sprint(o.Polisher),
+		"every":    strconv.Itoa(o.Every),
+		"budget":   strconv.Itoa(o.Budget),
+	}
+}
+
+// Memetic wraps a population, polishing its best prices with a local search every Every steps
+// polished prices that improve on the best are injected back into the population
+type Memetic struct {
+	Population   Injectable
+	Polishes     int       // polishes run since Init
+	Improvements []float64 // revenue gained by each polish, 0 when it failed to improve
+	opts         MemeticOptions
+	problem      pp.Problem
+	steps        int
+}
+
+// NewMemetic wraps population, polishing according to opts
+func NewMemetic(population Injectable, opts MemeticOptions) *Memetic {
+	return &Memetic{Population: population, opts: opts}
+}
+
+// Init initialises the population for problem pr, discarding any previous progress
+func (m *Memetic) Init(pr pp.Problem) {
+	m.problem = pr
+	m.Population.Init(pr)
+	m.Polishes, m.Improvements, m.steps = 0, []float64{}, 0
+}
+
+// Params lists the parameters of the population and of the polishing
+func (m *Memetic) Params() map[string]string {
+	params := m.opts.Params()
+	if po, ok := m.Population.(Parameterised); ok {
+		for k, v := range po.Params() {
+			params[k] = v
+		}
+	}
+	return params
+}
+
+// Best returns the best prices found by the population, including those injected by polishing
+func (m *Memetic) Best() ([]float64, float64) {
+	return m.Population.Best()
+}
+
+// Step steps the population, then polishes its best prices every Every steps
+func (m *Memetic) Step() {
+	m.Population.Step()
+	m.steps++
+	if m.opts.Every <= 0 || m.steps%m.opts.Every != 0 {
+		return
+	}
+	prices, revenue := m.Population.Best()
+	polished, polishedRevenue := m.opts.Polisher.Polish(prices, m.problem, m.opts.Budget)
+	m.Polishes++
+	improvement := 0.0
+	if polishedRevenue > revenue {
+		m.Population.Inject(polished, polishedRevenue)
+		improvement = polishedRevenue - revenue
+	}
+	m.Improvements = append(m.Improvements, improvement)
+}
+
+// Improvement returns the total revenue gained by polishing, and the number of polishes that improved the best
+func (m *Memetic) Improvement() (float64, int) {
+	var total float64
+	var improved int
+	for _, i := range m.Improvements {
+		total += i
+		if i > 0 {
+			improved++
+		}
+	}
+	return total, improved
+}
+
+// MemeticSearch runs population as a memetic algorithm, polishing its best prices with a local search
+// population is a pso.Swarm or ais.ImmuneSystem created with a nil problem, opts determines the polisher, how often it runs and its budget
+func MemeticSearch(ctx context.Context, population Injectable, opts MemeticOptions, stop StopCriterion, trace bool, p pp.Problem) (float64, []float64) {
+	m := NewMemetic(population, opts)
+	fmt.Printf("Memetic population created (%v polishing)...\n", opts.Polisher)
+	result := Run(ctx, m, p, stop, trace)
+	report(result)
+	total, improved := m.Improvement()
+	fmt.Printf("Polishes : %v, improved the best %v times, by %.2f in total", m.Polishes, improved, total)
+	if result.Revenue > 0 && !math.IsInf(result.Revenue, 1) {
+		fmt.Printf(" (%.2f%% of the final revenue)", 100*total/result.Revenue)
+	}
+	fmt.Println()
+	return result.Revenue, result.Trace
+}
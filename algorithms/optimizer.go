@@ -0,0 +1,150 @@
+package algorithms
+
+import (
+	"context"
+	"time"
+
+	"github.com/aagoldingay/ci-cw-go/ais"
+	"github.com/aagoldingay/ci-cw-go/bayesopt"
+	"github.com/aagoldingay/ci-cw-go/cmaes"
+	"github.com/aagoldingay/ci-cw-go/de"
+	"github.com/aagoldingay/ci-cw-go/ga"
+	"github.com/aagoldingay/ci-cw-go/localsearch"
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+	"github.com/aagoldingay/ci-cw-go/pso"
+	"github.com/aagoldingay/ci-cw-go/tabu"
+)
+
+// traceInterval is how often the best revenue is recorded when tracing
+const traceInterval = 5 * time.Millisecond
+
+// Optimizer is a search algorithm that can be run by Run
+// pso.Swarm, ais.ImmuneSystem, ais.Network, bayesopt.Optimiser, cmaes.ES, de.Population, ga.Population,
+// localsearch.Annealer, localsearch.HillClimber, localsearch.CoordinateAscent,
+// tabu.Search, Memetic and RandomSearcher all satisfy Optimizer
+type Optimizer interface {
+	// Init creates the starting state of the search for problem pr, discarding any previous progress
+	Init(pr pp.Problem)
+	// Step progresses the search by one iteration
+	Step()
+	// Best returns the best prices found so far, and their revenue
+	Best() ([]float64, float64)
+}
+
+// Parameterised is implemented by optimisers which report the parameters they run with
+type Parameterised interface {
+	Params() map[string]string
+}
+
+// compile time checks that each algorithm satisfies Optimizer
+var (
+	_ Optimizer = (*pso.Swarm)(nil)
+	_ Optimizer = (*ais.ImmuneSystem)(nil)
+	_ Optimizer = (*ais.Network)(nil)
+	_ Optimizer = (*bayesopt.Optimiser)(nil)
+	_ Optimizer = (*cmaes.ES)(nil)
+	_ Optimizer = (*de.Population)(nil)
+	_ Optimizer = (*ga.Population)(nil)
+	_ Optimizer = (*localsearch.Annealer)(nil)
+	_ Optimizer = (*localsearch.HillClimber)(nil)
+	_ Optimizer = (*localsearch.CoordinateAscent)(nil)
+	_ Optimizer = (*tabu.Search)(nil)
+	_ Optimizer = (*Memetic)(nil)
+	_ Optimizer = (*RandomSearcher)(nil)
+
+	_ Parameterised = (*pso.Swarm)(nil)
+	_ Parameterised = (*ais.ImmuneSystem)(nil)
+	_ Parameterised = (*ais.Network)(nil)
+	_ Parameterised = (*bayesopt.Optimiser)(nil)
+	_ Parameterised = (*cmaes.ES)(nil)
+	_ Parameterised = (*de.Population)(nil)
+	_ Parameterised = (*ga.Population)(nil)
+	_ Parameterised = (*localsearch.Annealer)(nil)
+	_ Parameterised = (*localsearch.HillClimber)(nil)
+	_ Parameterised = (*localsearch.CoordinateAscent)(nil)
+	_ Parameterised = (*tabu.Search)(nil)
+	_ Parameterised = (*Memetic)(nil)
+)
+
+// Result is the outcome of a run
+type Result struct {
+	Prices             []float64
+	Revenue            float64
+	Trace              []float64 // best revenue every traceInterval, followed by the final revenue
+	Steps, Evaluations int
+	Elapsed            time.Duration
+	StoppedBy          string            // the reason given by the stopping criterion that ended the run
+	Params             map[string]string // the parameters of the optimiser, if it is Parameterised
+}
+
+// Run initialises o for problem p and steps it until stop is met or ctx is done
+// if stop is nil, the run ends at the deadline of ctx, or after DefaultStop if ctx has no deadline
+// ctx is checked between steps, so a cancelled run returns the best result so far once the current step ends,
+// with the error of ctx as the reason it stopped
+// when trace is set, the best revenue is recorded every traceInterval, the final revenue is always recorded
+func Run(ctx context.Context, o Optimizer, p pp.Problem, stop StopCriterion, trace bool) Result {
+	if stop == nil {
+		stop = DefaultStop
+		if _, ok := ctx.Deadline(); ok {
+			stop = Any() // never met, so the run ends when ctx is done
+		}
+	}
+	revenueTrack := []float64{}
+	var params map[string]string
+	if po, ok := o.(Parameterised); ok {
+		params = po.Params()
+	}
+	counter := &countingProblem{Problem: p}
+	start := time.Now()
+	o.Init(counter)
+
+	state := RunState{Evaluations: counter.evaluations}
+	_, state.Revenue = o.Best()
+
+	tick := time.NewTicker(traceInterval)
+	defer tick.Stop()
+
+	for {
+		state.Elapsed = time.Since(start)
+		done, reason := stop.Met(state)
+		if err := ctx.Err(); err != nil {
+			done, reason = true, err.Error()
+		}
+		// criterion met or cancelled, stop running with the best result so far
+		if done {
+			prices, revenue := o.Best()
+			revenueTrack = append(revenueTrack, revenue) // adds final result
+			return Result{prices, revenue, revenueTrack, state.Steps, state.Evaluations, state.Elapsed, reason, params}
+		}
+
+		select {
+		// tick reached, record data
+		case <-tick.C:
+			if trace {
+				revenueTrack = append(revenueTrack, state.Revenue)
+			}
+		// run procedure
+		default:
+			o.Step()
+			state.Steps++
+			state.Evaluations = counter.evaluations
+			if _, revenue := o.Best(); revenue > state.Revenue {
+				state.Revenue = revenue
+				state.Stagnant = 0
+			} else {
+				state.Stagnant++
+			}
+		}
+	}
+}
+
+// countingProblem counts calls to Evaluate on the wrapped problem
+type countingProblem struct {
+	pp.Problem
+	evaluations int
+}
+
+func (c *countingProblem) Evaluate(prices []float64) (float64, error) {
+	c.evaluations++
+	return c.Problem.Evaluate(prices)
+}
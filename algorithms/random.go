@@ -0,0 +1,60 @@
+package algorithms
+
+import (
+	"log"
+	"math/rand"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// RandomSearcher compares an array of random prices against the current best Revenue each step
+// (This method was translated from the provided Java code)
+type RandomSearcher struct {
+	best      Revenue
+	newPrices []float64
+	problem   pp.Problem
+}
+
+// Init generates the first random prices for problem pr
+func (rs *RandomSearcher) Init(pr pp.Problem) {
+	rs.problem = pr
+	rs.best = Revenue{randomPrices(pr), 0}
+	rs.best.revenue = evaluate(rs.best.prices, pr)
+	rs.newPrices = make([]float64, len(rs.best.prices))
+}
+
+// Step generates and evaluates one new array of random prices, keeping it if it is the best so far
+func (rs *RandomSearcher) Step() {
+	for j := 0; j < len(rs.newPrices); j++ {
+		rs.newPrices[j] = rs.problem.Bounds()[j][0] + rand.Float64()*(rs.problem.Bounds()[j][1]-rs.problem.Bounds()[j][0])
+	}
+
+	newRevenue := evaluate(rs.newPrices, rs.problem)
+	if newRevenue > rs.best.revenue {
+		copy(rs.best.prices, rs.newPrices)
+		rs.best.revenue = newRevenue
+	}
+}
+
+// Best returns a copy of the best prices found so far, and their revenue
+func (rs *RandomSearcher) Best() ([]float64, float64) {
+	return append([]float64{}, rs.best.prices...), rs.best.revenue
+}
+
+// randomPrices generates random prices within the bounds of the problem
+func randomPrices(pr pp.Problem) []float64 {
+	prices := make([]float64, len(pr.Bounds()))
+	for i := 0; i < len(prices); i++ {
+		prices[i] = pr.Bounds()[i][0] + rand.Float64()*(pr.Bounds()[i][1]-pr.Bounds()[i][0])
+	}
+	return prices
+}
+
+// evaluate calculates the revenue for the provided prices
+func evaluate(prices []float64, pr pp.Problem) float64 {
+	revenue, err := pr.Evaluate(prices)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return revenue
+}
@@ -0,0 +1,113 @@
+package algorithms
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// RunState is the progress of a run, as seen by stopping criteria
+type RunState struct {
+	Elapsed     time.Duration
+	Steps       int
+	Evaluations int     // calls to Evaluate on the problem, including initialisation
+	Revenue     float64 // best revenue so far
+	Stagnant    int     // steps since the best revenue last improved
+}
+
+// StopCriterion decides when a run should end
+type StopCriterion interface {
+	// Met reports whether the run should stop, and describes the reason
+	Met(s RunState) (bool, string)
+}
+
+type timeLimitCriterion time.Duration
+
+// TimeLimit stops a run once d of wall-clock time has passed
+func TimeLimit(d time.Duration) StopCriterion {
+	return timeLimitCriterion(d)
+}
+
+func (c timeLimitCriterion) Met(s RunState) (bool, string) {
+	return s.Elapsed >= time.Duration(c), fmt.Sprintf("time limit %v", time.Duration(c))
+}
+
+type maxStepsCriterion int
+
+// MaxSteps stops a run after n steps
+func MaxSteps(n int) StopCriterion {
+	return maxStepsCriterion(n)
+}
+
+func (c maxStepsCriterion) Met(s RunState) (bool, string) {
+	return s.Steps >= int(c), fmt.Sprintf("%v steps", int(c))
+}
+
+type maxEvaluationsCriterion int
+
+// MaxEvaluations stops a run once n revenue evaluations have been made
+// evaluations are checked between steps, so a step may take the total past n
+func MaxEvaluations(n int) StopCriterion {
+	return maxEvaluationsCriterion(n)
+}
+
+func (c maxEvaluationsCriterion) Met(s RunState) (bool, string) {
+	return s.Evaluations >= int(c), fmt.Sprintf("%v evaluations", int(c))
+}
+
+type targetRevenueCriterion float64
+
+// TargetRevenue stops a run once the best revenue reaches revenue
+func TargetRevenue(revenue float64) StopCriterion {
+	return targetRevenueCriterion(revenue)
+}
+
+func (c targetRevenueCriterion) Met(s RunState) (bool, string) {
+	return s.Revenue >= float64(c), fmt.Sprintf("target revenue %v", float64(c))
+}
+
+type noImprovementCriterion int
+
+// NoImprovement stops a run once the best revenue has not improved for n steps
+func NoImprovement(n int) StopCriterion {
+	return noImprovementCriterion(n)
+}
+
+func (c noImprovementCriterion) Met(s RunState) (bool, string) {
+	return s.Stagnant >= int(c), fmt.Sprintf("no improvement for %v steps", int(c))
+}
+
+type anyCriterion []StopCriterion
+
+// Any stops a run when at least one of criteria is met (OR)
+func Any(criteria ...StopCriterion) StopCriterion {
+	return anyCriterion(criteria)
+}
+
+func (c anyCriterion) Met(s RunState) (bool, string) {
+	for _, criterion := range c {
+		if met, reason := criterion.Met(s); met {
+			return true, reason
+		}
+	}
+	return false, ""
+}
+
+type allCriterion []StopCriterion
+
+// All stops a run only when every one of criteria is met (AND)
+func All(criteria ...StopCriterion) StopCriterion {
+	return allCriterion(criteria)
+}
+
+func (c allCriterion) Met(s RunState) (bool, string) {
+	reasons := make([]string, len(c))
+	for i, criterion := range c {
+		met, reason := criterion.Met(s)
+		if !met {
+			return false, ""
+		}
+		reasons[i] = reason
+	}
+	return len(c) > 0, strings.Join(reasons, " and ")
+}
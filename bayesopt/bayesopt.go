@@ -0,0 +1,217 @@
+package bayesopt
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Optimiser is a Bayesian optimiser, fitting a Gaussian process surrogate to every revenue evaluated
+// and evaluating the prices that maximise an acquisition function of its predictions
+// prices are modelled as fractions of each good's bound range
+type Optimiser struct {
+	BestPrices  []float64
+	BestRevenue float64
+	Evaluations int       // revenues evaluated since Init
+	Exhausted   bool      // the budget has been used, further steps do nothing
+	Predicted   []float64 // predicted revenue of each acquired price, for comparison with the revenues evaluated
+	x           [][]float64
+	y           []float64
+	surrogate   *gp
+	opts        Options
+	problem     pp.Problem
+}
+
+// NewOptimiser creates a Bayesian optimiser, modelling and acquiring according to opts
+// if pr is nil, the optimiser is left empty until Init is called
+func NewOptimiser(opts Options, pr pp.Problem) *Optimiser {
+	bo := new(Optimiser)
+	bo.opts = opts
+	if pr != nil {
+		bo.Init(pr)
+	}
+	return bo
+}
+
+// Init evaluates the initial random design for problem pr, discarding any previous progress
+// the design is cut short by a Budget below Initial, and a Budget of 0 or less evaluates nothing
+func (bo *Optimiser) Init(pr pp.Problem) {
+	bo.problem = pr
+	bo.BestPrices, bo.BestRevenue = nil, math.Inf(-1)
+	bo.Evaluations, bo.Exhausted = 0, false
+	bo.Predicted = []float64{}
+	bo.x, bo.y = [][]float64{}, []float64{}
+	bo.surrogate = &gp{kernel: bo.opts.Kernel}
+
+	initial := bo.opts.Initial
+	if initial < 2 {
+		initial = 2 // the surrogate needs a spread of revenues
+	}
+	if initial > bo.opts.Budget {
+		initial = bo.opts.Budget // the design is part of the budget
+	}
+	if initial < 0 {
+		initial = 0
+	}
+	for _, x := range latinHypercube(initial, len(pr.Bounds())) {
+		bo.evaluate(x)
+	}
+	bo.Exhausted = bo.Evaluations >= bo.opts.Budget
+}
+
+// Options returns the parameters of the optimiser
+func (bo *Optimiser) Options() Options {
+	return bo.opts
+}
+
+// Params lists the parameters of the optimiser, for recording alongside results
+func (bo *Optimiser) Params() map[string]string {
+	return bo.opts.Params()
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (bo *Optimiser) Best() ([]float64, float64) {
+	return append([]float64{}, bo.BestPrices...), bo.BestRevenue
+}
+
+// Step fits the surrogate to every revenue evaluated, then evaluates the prices maximising the acquisition function
+func (bo *Optimiser) Step() {
+	if bo.Exhausted {
+		return
+	}
+	if err := bo.surrogate.fit(bo.x, bo.y, bo.opts.FitSamples); err != nil {
+		// no usable surrogate, so explore at random
+		bo.evaluate(latinHypercube(1, len(bo.problem.Bounds()))[0])
+		return
+	}
+	x := bo.maximiseAcquisition()
+	mean, _ := bo.surrogate.predict(x)
+	bo.Predicted = append(bo.Predicted, bo.surrogate.mean+mean*bo.surrogate.std)
+	bo.evaluate(x)
+}
+
+// evaluate evaluates normalised prices x, updating the observations, the best prices and the budget
+// invalid prices are observed with the lowest revenue evaluated so far, steering the surrogate away from them
+func (bo *Optimiser) evaluate(x []float64) {
+	bounds := bo.problem.Bounds()
+	prices := make([]float64, len(x))
+	for i := range x {
+		prices[i] = bounds[i][0] + x[i]*(bounds[i][1]-bounds[i][0])
+	}
+	var rev float64
+	if bo.problem.IsValid(prices) {
+		var err error
+		if rev, err = bo.problem.Evaluate(prices); err != nil {
+			log.Fatal(err)
+		}
+		if rev > bo.BestRevenue {
+			bo.BestPrices, bo.BestRevenue = prices, rev
+		}
+	} else if len(bo.y) > 0 {
+		rev = bo.y[0]
+		for _, v := range bo.y {
+			rev = math.Min(rev, v)
+		}
+	}
+	bo.Evaluations++ // invalid prices count against the budget too, so invalid regions cannot stall the search
+	bo.x = append(bo.x, x)
+	bo.y = append(bo.y, rev)
+	bo.Exhausted = bo.Evaluations >= bo.opts.Budget
+}
+
+// acquisition scores normalised prices x, higher is more worth evaluating
+func (bo *Optimiser) acquisition(x []float64, best float64) float64 {
+	mean, sd := bo.surrogate.predict(x)
+	if bo.opts.Acquisition == UpperConfidenceBound {
+		return mean + bo.opts.Kappa*sd
+	}
+	improvement := mean - best - bo.opts.Xi
+	z := improvement / sd
+	return improvement*normalCDF(z) + sd*normalPDF(z)
+}
+
+// maximiseAcquisition scores Samples random prices, then climbs from the Restarts best, returning the best found
+func (bo *Optimiser) maximiseAcquisition() []float64 {
+	n := len(bo.problem.Bounds())
+	best := math.Inf(-1)
+	for _, v := range bo.surrogate.y {
+		best = math.Max(best, v)
+	}
+
+	type scored struct {
+		x     []float64
+		score float64
+	}
+	candidates := make([]scored, 0, bo.opts.Samples+1)
+	for _, x := range latinHypercube(bo.opts.Samples, n) {
+		candidates = append(candidates, scored{x, bo.acquisition(x, best)})
+	}
+	// the best observed prices are a start, as the acquisition is often highest close by
+	for i, v := range bo.surrogate.y {
+		if v == best {
+			x := append([]float64{}, bo.x[i]...)
+			candidates = append(candidates, scored{x, bo.acquisition(x, best)})
+			break
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+
+	restarts := bo.opts.Restarts
+	if restarts > len(candidates) {
+		restarts = len(candidates)
+	}
+	winner := candidates[0]
+	for _, c := range candidates[:restarts] {
+		// stochastic hill climb with a shrinking step
+		step := 0.1
+		for k := 0; k < 20*n && step > 1e-4; k++ {
+			trial := append([]float64{}, c.x...)
+			i := rand.Intn(n)
+			trial[i] = math.Min(math.Max(trial[i]+rand.NormFloat64()*step, 0), 1)
+			if s := bo.acquisition(trial, best); s > c.score {
+				c = scored{trial, s}
+			} else {
+				step *= 0.95
+			}
+		}
+		if c.score > winner.score {
+			winner = c
+		}
+	}
+	return winner.x
+}
+
+// latinHypercube returns n points of [0, 1]^d, with one point in each of n equal slices of every dimension
+func latinHypercube(n, d int) [][]float64 {
+	points := make([][]float64, n)
+	for i := range points {
+		points[i] = make([]float64, d)
+	}
+	for j := 0; j < d; j++ {
+		for i, slice := range rand.Perm(n) {
+			points[i][j] = (float64(slice) + rand.Float64()) / float64(n)
+		}
+	}
+	return points
+}
+
+// normalPDF is the standard normal density
+func normalPDF(z float64) float64 {
+	return math.Exp(-z*z/2) / math.Sqrt(2*math.Pi)
+}
+
+// normalCDF is the standard normal distribution function
+func normalCDF(z float64) float64 {
+	return 0.5 * math.Erfc(-z/math.Sqrt2)
+}
+
+// Surrogate describes the fitted hyperparameters of the surrogate, for reporting
+func (bo *Optimiser) Surrogate() string {
+	h := bo.surrogate.params
+	return bo.opts.Kernel.String() + " lengthscale " + strconv.FormatFloat(h.lengthscale, 'f', 3, 64) +
+		", variance " + strconv.FormatFloat(h.variance, 'f', 3, 64) + ", noise " + strconv.FormatFloat(h.noise, 'g', 3, 64)
+}
@@ -0,0 +1,196 @@
+package bayesopt
+
+import (
+	"fmt"
+	"math"
+	"testing"
+)
+
+func Test_gpInterpolates(t *testing.T) {
+	x := [][]float64{{0.1}, {0.3}, {0.5}, {0.7}, {0.9}}
+	y := []float64{1, 3, 4, 3, 1}
+	for _, k := range Kernels {
+		g := &gp{kernel: k}
+		if err := g.fit(x, y, 50); err != nil {
+			t.Fatal(err)
+		}
+		for i := range x {
+			mean, sd := g.predict(x[i])
+			if rev := g.mean + mean*g.std; math.Abs(rev-y[i]) > 0.5 {
+				t.Errorf("%v predicted %v at %v, observed %v", k, rev, x[i], y[i])
+			}
+			// at an observation, the prediction is no less certain than the fitted noise
+			if sd > math.Sqrt(g.params.noise)+1e-9 {
+				t.Errorf("%v predicted standard deviation %v at observed %v, noise %v", k, sd, x[i], g.params.noise)
+			}
+		}
+		// further from the observations, the prediction is less certain
+		_, near := g.predict([]float64{0.4})
+		_, far := g.predict([]float64{3})
+		if far <= near {
+			t.Errorf("%v standard deviation %v far from the observations, %v near", k, far, near)
+		}
+	}
+}
+
+// bowl earns 100 less the squared distance of prices from centre, within bounds [0, 10]
+// prices of good 0 above ceiling are invalid
+type bowl struct {
+	centre  []float64
+	ceiling float64
+}
+
+func (b bowl) Evaluate(prices []float64) (float64, error) {
+	rev := 100.0
+	for i, x := range prices {
+		rev -= (x - b.centre[i]) * (x - b.centre[i])
+	}
+	return rev, nil
+}
+
+func (b bowl) Bounds() [][]float64 {
+	bounds := make([][]float64, len(b.centre))
+	for i := range bounds {
+		bounds[i] = []float64{0, 10}
+	}
+	return bounds
+}
+
+func (b bowl) IsValid(prices []float64) bool {
+	for _, x := range prices {
+		if x < 0 || x > 10 {
+			return false
+		}
+	}
+	return prices[0] <= b.ceiling
+}
+
+// fitted returns an optimiser for one good whose surrogate is fitted to revenues peaking at 0.5
+func fitted(acquisition Acquisition) *Optimiser {
+	opts := DefaultOptions()
+	opts.Acquisition = acquisition
+	bo := NewOptimiser(opts, nil)
+	bo.problem = bowl{centre: []float64{5}, ceiling: 10}
+	bo.surrogate = &gp{kernel: opts.Kernel}
+	bo.x = [][]float64{{0.1}, {0.3}, {0.5}, {0.7}, {0.9}}
+	bo.y = []float64{1, 3, 4, 3, 1}
+	if err := bo.surrogate.fit(bo.x, bo.y, opts.FitSamples); err != nil {
+		panic(err)
+	}
+	return bo
+}
+
+func Test_acquisition(t *testing.T) {
+	// expected improvement is never negative, and vanishes where the revenue is surely below the best
+	bo := fitted(ExpectedImprovement)
+	best := bo.surrogate.y[2]
+	for x := 0.0; x <= 1; x += 0.01 {
+		if ei := bo.acquisition([]float64{x}, best); ei < 0 || math.IsNaN(ei) {
+			t.Errorf("expected improvement %v at %v", ei, x)
+		}
+	}
+	if ei := bo.acquisition([]float64{0.1}, best); ei > 1e-3 {
+		t.Errorf("expected improvement %v at the lowest observed revenue", ei)
+	}
+	if far, observed := bo.acquisition([]float64{0.5}, best+10), bo.acquisition([]float64{0.5}, best); far > 1e-9 || observed <= far {
+		t.Errorf("expected improvement %v over an unreachable best, %v over the observed best", far, observed)
+	}
+
+	// the upper confidence bound is the predicted mean plus Kappa standard deviations
+	bo = fitted(UpperConfidenceBound)
+	for _, x := range []float64{0, 0.25, 0.5, 2} {
+		mean, sd := bo.surrogate.predict([]float64{x})
+		if ucb := bo.acquisition([]float64{x}, best); math.Abs(ucb-(mean+2*sd)) > 1e-12 {
+			t.Errorf("upper confidence bound %v at %v, expected %v", ucb, x, mean+2*sd)
+		}
+	}
+}
+
+func Test_maximiseAcquisition(t *testing.T) {
+	for _, a := range Acquisitions {
+		bo := fitted(a)
+		best := bo.surrogate.y[2]
+		highest := math.Inf(-1)
+		for x := 0.0; x <= 1; x += 0.001 {
+			highest = math.Max(highest, bo.acquisition([]float64{x}, best))
+		}
+		x := bo.maximiseAcquisition()
+		if x[0] < 0 || x[0] > 1 {
+			t.Errorf("%v : acquired %v, outside the normalised bounds", a, x)
+		}
+		if score := bo.acquisition(x, best); score < highest-1e-3*math.Abs(highest) {
+			t.Errorf("%v : acquired %v scoring %v, the maximum is %v", a, x, score, highest)
+		}
+	}
+}
+
+func Test_budget(t *testing.T) {
+	pr := bowl{centre: []float64{2, 7}, ceiling: 10}
+	tests := []struct {
+		budget, initial int
+		design          int // evaluations made by Init
+	}{
+		{15, 5, 5},
+		{3, 10, 3}, // the budget cuts the design short
+		{10, 0, 2}, // the surrogate needs at least two revenues
+		{0, 10, 0}, // no budget evaluates nothing
+		{-3, 10, 0},
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Budget, opts.Initial, opts.Samples = tt.budget, tt.initial, 50
+		bo := NewOptimiser(opts, pr)
+		if bo.Evaluations != tt.design || bo.Exhausted != (tt.design >= tt.budget) {
+			t.Errorf("budget %v, initial %v : %v evaluations in the design, exhausted %v", tt.budget, tt.initial, bo.Evaluations, bo.Exhausted)
+		}
+		for i := 0; i < 2*tt.budget; i++ {
+			bo.Step()
+		}
+		spent := tt.budget
+		if spent < 0 {
+			spent = 0
+		}
+		if bo.Evaluations != spent || !bo.Exhausted || len(bo.Predicted) != spent-tt.design || len(bo.y) != spent {
+			t.Errorf("budget %v : %v evaluations, %v predictions, exhausted %v", tt.budget, bo.Evaluations, len(bo.Predicted), bo.Exhausted)
+		}
+	}
+}
+
+func Test_invalidPrices(t *testing.T) {
+	pr := bowl{centre: []float64{5, 5}, ceiling: 5}
+	bo := NewOptimiser(DefaultOptions(), nil)
+	bo.Init(pr)
+	bo.x, bo.y, bo.Evaluations = [][]float64{}, []float64{}, 0
+	bo.BestPrices, bo.BestRevenue = nil, math.Inf(-1)
+
+	// invalid prices are observed with the lowest revenue so far, and count against the budget
+	bo.evaluate([]float64{0.5, 0.5})
+	bo.evaluate([]float64{0.9, 0.5})
+	bo.evaluate([]float64{0.2, 0.5})
+	bo.evaluate([]float64{0.8, 0.1})
+	if fmt.Sprint(bo.y) != "[100 100 91 91]" || bo.Evaluations != 4 {
+		t.Errorf("observed %v after %v evaluations", bo.y, bo.Evaluations)
+	}
+	if fmt.Sprint(bo.BestPrices) != "[5 5]" || bo.BestRevenue != 100 {
+		t.Errorf("best %v with revenue %v, expected the valid peak", bo.BestPrices, bo.BestRevenue)
+	}
+	// or 0 before any revenue has been observed
+	bo.x, bo.y = [][]float64{}, []float64{}
+	if bo.evaluate([]float64{0.9, 0.5}); bo.y[0] != 0 {
+		t.Errorf("observed %v for invalid prices before any revenue", bo.y)
+	}
+
+	// a search with an invalid half only reports valid prices, approaching the peak on the ceiling
+	opts := DefaultOptions()
+	opts.Budget = 40
+	bo = NewOptimiser(opts, bowl{centre: []float64{8, 3}, ceiling: 5})
+	for !bo.Exhausted {
+		bo.Step()
+		if bo.BestPrices != nil && bo.BestPrices[0] > 5 {
+			t.Fatalf("best prices %v are invalid", bo.BestPrices)
+		}
+	}
+	if bo.BestRevenue < 80 {
+		t.Errorf("found %v at %v, expected close to %v at [5 3]", bo.BestRevenue, bo.BestPrices, 100-9)
+	}
+}
@@ -0,0 +1,187 @@
+package bayesopt
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+)
+
+// hyper are the hyperparameters of a Gaussian process
+type hyper struct {
+	lengthscale float64 // distance over which revenues are correlated, in normalised prices
+	variance    float64 // prior variance of the normalised revenue
+	noise       float64 // variance of the observation noise, also keeping the covariance matrix well conditioned
+}
+
+// gp is a Gaussian process regression of normalised revenues on prices normalised to [0, 1]
+// revenues are standardised to zero mean and unit variance before fitting
+type gp struct {
+	kernel    Kernel
+	params    hyper
+	x         [][]float64
+	y         []float64 // standardised revenues
+	mean, std float64   // of the observed revenues
+	chol      [][]float64
+	alpha     []float64 // K^-1 y
+}
+
+// covariance returns the kernel between a and b
+func (g *gp) covariance(a, b []float64, h hyper) float64 {
+	var sq float64
+	for i := range a {
+		sq += (a[i] - b[i]) * (a[i] - b[i])
+	}
+	r := math.Sqrt(sq) / h.lengthscale
+	if g.kernel == Matern52 {
+		return h.variance * (1 + math.Sqrt(5)*r + 5*r*r/3) * math.Exp(-math.Sqrt(5)*r)
+	}
+	return h.variance * math.Exp(-r*r/2)
+}
+
+// fit conditions the process on revenues y at normalised prices x
+// the hyperparameters are chosen from the current ones and samples random candidates
+// by the highest log marginal likelihood
+func (g *gp) fit(x [][]float64, y []float64, samples int) error {
+	g.x = x
+	g.mean, g.std = 0, 0
+	for _, v := range y {
+		g.mean += v
+	}
+	g.mean /= float64(len(y))
+	for _, v := range y {
+		g.std += (v - g.mean) * (v - g.mean)
+	}
+	g.std = math.Sqrt(g.std / float64(len(y)))
+	if g.std == 0 {
+		g.std = 1
+	}
+	g.y = make([]float64, len(y))
+	for i, v := range y {
+		g.y[i] = (v - g.mean) / g.std
+	}
+
+	scale := math.Sqrt(float64(len(x[0]))) // largest distance between normalised prices
+	candidates := []hyper{}
+	if g.params.lengthscale > 0 {
+		candidates = append(candidates, g.params)
+	}
+	for k := 0; k < samples; k++ {
+		candidates = append(candidates, hyper{
+			lengthscale: scale * math.Exp(logUniform(0.02, 2)),
+			variance:    math.Exp(logUniform(0.1, 10)),
+			noise:       math.Exp(logUniform(1e-6, 1e-1)),
+		})
+	}
+	best, bestLikelihood := hyper{}, math.Inf(-1)
+	for _, h := range candidates {
+		if l, err := g.logLikelihood(h); err == nil && l > bestLikelihood {
+			best, bestLikelihood = h, l
+		}
+	}
+	if math.IsInf(bestLikelihood, -1) {
+		return errors.New("gp::fit no hyperparameters gave a positive definite covariance")
+	}
+	g.params = best
+	_, err := g.logLikelihood(best) // leaves chol and alpha for the best hyperparameters
+	return err
+}
+
+// logLikelihood returns the log marginal likelihood of the observations under hyperparameters h,
+// setting chol and alpha
+func (g *gp) logLikelihood(h hyper) (float64, error) {
+	n := len(g.x)
+	k := make([][]float64, n)
+	for i := range k {
+		k[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			k[i][j] = g.covariance(g.x[i], g.x[j], h)
+			k[j][i] = k[i][j]
+		}
+		k[i][i] += h.noise
+	}
+	chol, err := cholesky(k)
+	if err != nil {
+		return 0, err
+	}
+	g.chol = chol
+	g.alpha = solveUpper(chol, solveLower(chol, g.y))
+
+	// -1/2 y^T K^-1 y - 1/2 log|K| - n/2 log 2pi
+	var fitTerm, logDet float64
+	for i := range g.y {
+		fitTerm += g.y[i] * g.alpha[i]
+		logDet += math.Log(chol[i][i])
+	}
+	return -fitTerm/2 - logDet - float64(n)/2*math.Log(2*math.Pi), nil
+}
+
+// predict returns the predicted mean and standard deviation of the standardised revenue at normalised prices x
+func (g *gp) predict(x []float64) (float64, float64) {
+	k := make([]float64, len(g.x))
+	var mean float64
+	for i := range g.x {
+		k[i] = g.covariance(x, g.x[i], g.params)
+		mean += k[i] * g.alpha[i]
+	}
+	v := solveLower(g.chol, k)
+	variance := g.params.variance
+	for _, vi := range v {
+		variance -= vi * vi
+	}
+	return mean, math.Sqrt(math.Max(variance, 1e-12))
+}
+
+// cholesky returns the lower triangular L with L L^T = a, or an error if a is not positive definite
+func cholesky(a [][]float64) ([][]float64, error) {
+	n := len(a)
+	l := make([][]float64, n)
+	for i := range l {
+		l[i] = make([]float64, n)
+		for j := 0; j <= i; j++ {
+			sum := a[i][j]
+			for k := 0; k < j; k++ {
+				sum -= l[i][k] * l[j][k]
+			}
+			if i == j {
+				if sum <= 0 {
+					return nil, errors.New("cholesky::matrix is not positive definite")
+				}
+				l[i][i] = math.Sqrt(sum)
+			} else {
+				l[i][j] = sum / l[j][j]
+			}
+		}
+	}
+	return l, nil
+}
+
+// solveLower solves L x = b for lower triangular L
+func solveLower(l [][]float64, b []float64) []float64 {
+	x := make([]float64, len(b))
+	for i := range b {
+		sum := b[i]
+		for k := 0; k < i; k++ {
+			sum -= l[i][k] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// solveUpper solves L^T x = b for lower triangular L
+func solveUpper(l [][]float64, b []float64) []float64 {
+	x := make([]float64, len(b))
+	for i := len(b) - 1; i >= 0; i-- {
+		sum := b[i]
+		for k := i + 1; k < len(b); k++ {
+			sum -= l[k][i] * x[k]
+		}
+		x[i] = sum / l[i][i]
+	}
+	return x
+}
+
+// logUniform returns the log of a value drawn log uniformly from [low, high]
+func logUniform(low, high float64) float64 {
+	return math.Log(low) + rand.Float64()*(math.Log(high)-math.Log(low))
+}
@@ -0,0 +1,96 @@
+package bayesopt
+
+import "strconv"
+
+// Kernel determines the covariance between revenues at two sets of prices, by their distance
+type Kernel int
+
+const (
+	// RBF is the squared exponential kernel, modelling a very smooth revenue
+	RBF Kernel = iota
+	// Matern52 is the Matérn kernel with smoothness 5/2, modelling a revenue that is twice differentiable
+	Matern52
+)
+
+// Kernels lists every kernel, for comparisons
+var Kernels = []Kernel{RBF, Matern52}
+
+func (k Kernel) String() string {
+	switch k {
+	case RBF:
+		return "rbf"
+	case Matern52:
+		return "matern52"
+	}
+	return "unknown"
+}
+
+// Acquisition determines which prices are evaluated next, from the predictions of the surrogate
+type Acquisition int
+
+const (
+	// ExpectedImprovement chooses the prices with the greatest expected improvement over the best revenue
+	ExpectedImprovement Acquisition = iota
+	// UpperConfidenceBound chooses the prices with the greatest predicted revenue plus Kappa standard deviations
+	UpperConfidenceBound
+)
+
+// Acquisitions lists every acquisition function, for comparisons
+var Acquisitions = []Acquisition{ExpectedImprovement, UpperConfidenceBound}
+
+func (a Acquisition) String() string {
+	switch a {
+	case ExpectedImprovement:
+		return "ei"
+	case UpperConfidenceBound:
+		return "ucb"
+	}
+	return "unknown"
+}
+
+// Options configures an Optimiser
+type Options struct {
+	Kernel      Kernel
+	Acquisition Acquisition
+	Budget      int     // evaluations of the revenue, including the initial design
+	Initial     int     // random prices evaluated before the surrogate is used
+	Xi          float64 // ExpectedImprovement exploration, as standard deviations of the observed revenues
+	Kappa       float64 // UpperConfidenceBound exploration, in predicted standard deviations
+	Samples     int     // random prices scored by the acquisition function each step
+	Restarts    int     // best scoring samples climbed to a local maximum of the acquisition function
+	FitSamples  int     // hyperparameters tried each step when maximising the marginal likelihood
+}
+
+// DefaultOptions returns Bayesian optimisation parameters suited to the pricing problem,
+// a Matérn kernel and expected improvement
+func DefaultOptions() Options {
+	return Options{
+		Kernel:      Matern52,
+		Acquisition: ExpectedImprovement,
+		Budget:      100,
+		Initial:     10,
+		Xi:          0.01,
+		Kappa:       2,
+		Samples:     500,
+		Restarts:    5,
+		FitSamples:  50,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"kernel":      o.Kernel.String(),
+		"acquisition": o.Acquisition.String(),
+		"budget":      strconv.Itoa(o.Budget),
+		"initial":     strconv.Itoa(o.Initial),
+		"samples":     strconv.Itoa(o.Samples),
+		"restarts":    strconv.Itoa(o.Restarts),
+	}
+	if o.Acquisition == UpperConfidenceBound {
+		params["kappa"] = strconv.FormatFloat(o.Kappa, 'f', -1, 64)
+	} else {
+		params["xi"] = strconv.FormatFloat(o.Xi, 'f', -1, 64)
+	}
+	return params
+}
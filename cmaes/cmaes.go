@@ -0,0 +1,317 @@
+package cmaes
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// ES is a covariance matrix adaptation evolution strategy
+// prices are searched as fractions of each good's bound range, samples outside the bounds are repaired
+// to the nearest prices within the bounds, and the repaired samples are used to adapt the distribution
+type ES struct {
+	BestPrices  []float64
+	BestRevenue float64
+	Restarts    int
+	Lambda      int // population size of the current run
+	numGoods    int
+	problem     pp.Problem
+	opts        Options
+
+	// distribution
+	mean, pc, ps []float64
+	sigma        float64
+	c, b         [][]float64 // covariance matrix, and its eigenvectors as columns
+	d            []float64   // square roots of the eigenvalues of c
+
+	// strategy parameters of the current run
+	mu                                 int
+	weights                            []float64
+	mueff, cc, cs, c1, cmu, damps      float64
+	chiN                               float64
+	generation, eigenGeneration        int
+	history                            []float64 // best revenue of each generation of the current run
+	defaultLambda, largeLambda         int
+	largeEvaluations, smallEvaluations int
+	large                              bool // the current run is in the large population regime of BIPOP
+}
+
+// sample is one evaluated point of a generation
+type sample struct {
+	x, y    []float64 // normalised position, and its step from the mean divided by sigma
+	revenue float64
+}
+
+// NewES creates a new evolution strategy, sampling according to opts
+// if pr is nil, the strategy is left empty until Init is called
+func NewES(numGoods int, opts Options, pr pp.Problem) *ES {
+	es := new(ES)
+	es.numGoods = numGoods
+	es.opts = opts
+	if pr != nil {
+		es.Init(pr)
+	}
+	return es
+}
+
+// Init starts a new search for problem pr from a random mean, evaluating it, and discards any previous progress
+func (es *ES) Init(pr pp.Problem) {
+	es.problem = pr
+	es.numGoods = len(pr.Bounds())
+	es.BestPrices, es.BestRevenue = nil, math.Inf(-1)
+	es.Restarts = 0
+	es.defaultLambda = es.opts.Lambda
+	if es.defaultLambda <= 0 {
+		es.defaultLambda = 4 + int(3*math.Log(float64(es.numGoods)))
+	}
+	es.largeLambda = es.defaultLambda
+	es.largeEvaluations, es.smallEvaluations = 0, 0
+	es.large = true
+	es.start(es.defaultLambda, es.opts.Sigma)
+
+	// the starting mean is the best until the first generation is sampled
+	bounds := pr.Bounds()
+	prices := make([]float64, es.numGoods)
+	for i := range prices {
+		prices[i] = bounds[i][0] + es.mean[i]*(bounds[i][1]-bounds[i][0])
+	}
+	if pr.IsValid(prices) {
+		es.BestPrices = prices
+		es.BestRevenue, _ = pr.Evaluate(prices)
+	}
+}
+
+// Options returns the parameters of the strategy
+func (es *ES) Options() Options {
+	return es.opts
+}
+
+// Params lists the parameters of the strategy, for recording alongside results
+func (es *ES) Params() map[string]string {
+	params := es.opts.Params()
+	params["lambda"] = strconv.Itoa(es.defaultLambda)
+	return params
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (es *ES) Best() ([]float64, float64) {
+	return append([]float64{}, es.BestPrices...), es.BestRevenue
+}
+
+// start begins a run with population size lambda and step size sigma, from a random mean
+func (es *ES) start(lambda int, sigma float64) {
+	n := es.numGoods
+	es.Lambda = lambda
+	es.sigma = sigma
+	es.mean = make([]float64, n)
+	for i := range es.mean {
+		es.mean[i] = rand.Float64()
+	}
+	es.pc, es.ps = make([]float64, n), make([]float64, n)
+	es.c, es.b = identity(n), identity(n)
+	es.d = make([]float64, n)
+	for i := range es.d {
+		es.d[i] = 1
+	}
+
+	// strategy parameters (Hansen, The CMA Evolution Strategy: A Tutorial)
+	es.mu = lambda / 2
+	es.weights = make([]float64, es.mu)
+	var sum, sumSq float64
+	for i := range es.weights {
+		es.weights[i] = math.Log(float64(lambda+1)/2) - math.Log(float64(i+1))
+		sum += es.weights[i]
+	}
+	for i := range es.weights {
+		es.weights[i] /= sum
+		sumSq += es.weights[i] * es.weights[i]
+	}
+	es.mueff = 1 / sumSq
+	nf := float64(n)
+	es.cc = (4 + es.mueff/nf) / (nf + 4 + 2*es.mueff/nf)
+	es.cs = (es.mueff + 2) / (nf + es.mueff + 5)
+	es.c1 = 2 / ((nf+1.3)*(nf+1.3) + es.mueff)
+	es.cmu = math.Min(1-es.c1, 2*(es.mueff-2+1/es.mueff)/((nf+2)*(nf+2)+es.mueff))
+	es.damps = 1 + 2*math.Max(0, math.Sqrt((es.mueff-1)/(nf+1))-1) + es.cs
+	es.chiN = math.Sqrt(nf) * (1 - 1/(4*nf) + 1/(21*nf*nf))
+	es.generation, es.eigenGeneration = 0, 0
+	es.history = []float64{}
+}
+
+// Step samples and evaluates one generation, then adapts the distribution towards its best samples
+// restarts once the run has converged, according to the restart strategy
+func (es *ES) Step() {
+	n := es.numGoods
+	samples := make([]sample, es.Lambda)
+	for k := range samples {
+		samples[k] = es.sample()
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return samples[i].revenue > samples[j].revenue
+	})
+	if es.large {
+		es.largeEvaluations += len(samples)
+	} else {
+		es.smallEvaluations += len(samples)
+	}
+
+	// recombination
+	es.mean = make([]float64, n)
+	yw := make([]float64, n)
+	for k := 0; k < es.mu; k++ {
+		for i := 0; i < n; i++ {
+			es.mean[i] += es.weights[k] * samples[k].x[i]
+			yw[i] += es.weights[k] * samples[k].y[i]
+		}
+	}
+
+	// evolution paths, ps uses C^-1/2 * yw = B * D^-1 * B^T * yw
+	bty := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			bty[i] += es.b[j][i] * yw[j]
+		}
+		bty[i] /= es.d[i]
+	}
+	psFactor := math.Sqrt(es.cs * (2 - es.cs) * es.mueff)
+	var psNorm float64
+	for i := 0; i < n; i++ {
+		var invSqrt float64
+		for j := 0; j < n; j++ {
+			invSqrt += es.b[i][j] * bty[j]
+		}
+		es.ps[i] = (1-es.cs)*es.ps[i] + psFactor*invSqrt
+		psNorm += es.ps[i] * es.ps[i]
+	}
+	psNorm = math.Sqrt(psNorm)
+	hsig := 0.0
+	if psNorm/math.Sqrt(1-math.Pow(1-es.cs, 2*float64(es.generation+1)))/es.chiN < 1.4+2/float64(n+1) {
+		hsig = 1
+	}
+	pcFactor := math.Sqrt(es.cc * (2 - es.cc) * es.mueff)
+	for i := 0; i < n; i++ {
+		es.pc[i] = (1-es.cc)*es.pc[i] + hsig*pcFactor*yw[i]
+	}
+
+	// covariance matrix, rank one and rank mu updates
+	for i := 0; i < n; i++ {
+		for j := 0; j <= i; j++ {
+			rankMu := 0.0
+			for k := 0; k < es.mu; k++ {
+				rankMu += es.weights[k] * samples[k].y[i] * samples[k].y[j]
+			}
+			cij := (1-es.c1-es.cmu)*es.c[i][j] +
+				es.c1*(es.pc[i]*es.pc[j]+(1-hsig)*es.cc*(2-es.cc)*es.c[i][j]) +
+				es.cmu*rankMu
+			es.c[i][j], es.c[j][i] = cij, cij // kept exactly symmetric
+		}
+	}
+
+	// step size
+	es.sigma *= math.Exp((es.cs / es.damps) * (psNorm/es.chiN - 1))
+
+	es.generation++
+	if float64(es.generation-es.eigenGeneration) > float64(es.Lambda)/(es.c1+es.cmu)/float64(n)/10 {
+		es.decompose()
+	}
+
+	es.history = append(es.history, samples[0].revenue)
+	if es.converged() && es.opts.Restart != NoRestart && es.Restarts < es.opts.MaxRestarts {
+		es.restart()
+	}
+}
+
+// sample draws one point from the distribution, repairs it to within the bounds and evaluates it,
+// updating the best prices found
+func (es *ES) sample() sample {
+	n := es.numGoods
+	bounds := es.problem.Bounds()
+	z := make([]float64, n)
+	for i := range z {
+		z[i] = es.d[i] * rand.NormFloat64()
+	}
+	s := sample{x: make([]float64, n), y: make([]float64, n)}
+	prices := make([]float64, n)
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			s.y[i] += es.b[i][j] * z[j]
+		}
+		s.x[i] = math.Min(math.Max(es.mean[i]+es.sigma*s.y[i], 0), 1)
+		s.y[i] = (s.x[i] - es.mean[i]) / es.sigma
+		prices[i] = bounds[i][0] + s.x[i]*(bounds[i][1]-bounds[i][0])
+	}
+	s.revenue = math.Inf(-1)
+	if es.problem.IsValid(prices) {
+		s.revenue, _ = es.problem.Evaluate(prices)
+	}
+	if s.revenue > es.BestRevenue {
+		es.BestRevenue = s.revenue
+		es.BestPrices = prices
+	}
+	return s
+}
+
+// decompose updates b and d from the eigendecomposition of c
+func (es *ES) decompose() {
+	values, vectors := eigen(es.c)
+	es.b = vectors
+	for i := range values {
+		es.d[i] = math.Sqrt(math.Max(values[i], 1e-20))
+	}
+	es.eigenGeneration = es.generation
+}
+
+// converged checks whether the current run has stopped making progress
+// the step size is negligible, the covariance matrix is ill conditioned, or the best revenue has stagnated
+func (es *ES) converged() bool {
+	maxD, minD := es.d[0], es.d[0]
+	for _, d := range es.d {
+		maxD, minD = math.Max(maxD, d), math.Min(minD, d)
+	}
+	if es.sigma*maxD < es.opts.TolX || maxD > 1e7*minD {
+		return true
+	}
+
+	window := 10 + int(math.Ceil(30*float64(es.numGoods)/float64(es.Lambda)))
+	if len(es.history) < window {
+		return false
+	}
+	recent := es.history[len(es.history)-window:]
+	lowest, highest := recent[0], recent[0]
+	for _, v := range recent {
+		lowest, highest = math.Min(lowest, v), math.Max(highest, v)
+	}
+	return highest-lowest <= es.opts.TolFun*math.Max(1, math.Abs(highest))
+}
+
+// restart begins a new run from a random mean, with a population size and step size chosen by the restart strategy
+func (es *ES) restart() {
+	es.Restarts++
+	if es.opts.Restart == BIPOP && es.largeEvaluations > es.smallEvaluations {
+		// small population regime, population and step size drawn between the default and a tenth of the default
+		u := rand.Float64()
+		lambda := int(float64(es.defaultLambda) * math.Pow(0.5*float64(es.largeLambda)/float64(es.defaultLambda), u*u))
+		if lambda < 4 {
+			lambda = 4
+		}
+		es.large = false
+		es.start(lambda, es.opts.Sigma*math.Pow(10, -2*rand.Float64()))
+		return
+	}
+	es.largeLambda *= 2
+	es.large = true
+	es.start(es.largeLambda, es.opts.Sigma)
+}
+
+// identity returns the n by n identity matrix
+func identity(n int) [][]float64 {
+	m := make([][]float64, n)
+	for i := range m {
+		m[i] = make([]float64, n)
+		m[i][i] = 1
+	}
+	return m
+}
@@ -0,0 +1,175 @@
+package cmaes
+
+import (
+	"math"
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_eigen(t *testing.T) {
+	a := [][]float64{
+		{4, 1, 2},
+		{1, 3, 0.5},
+		{2, 0.5, 5},
+	}
+	values, vectors := eigen(a)
+
+	// a = B diag(values) B^T
+	for i := range a {
+		for j := range a {
+			var sum float64
+			for k := range values {
+				sum += vectors[i][k] * values[k] * vectors[j][k]
+			}
+			if math.Abs(sum-a[i][j]) > 1e-9 {
+				t.Errorf("reconstructed a[%v][%v] = %v, expected %v", i, j, sum, a[i][j])
+			}
+		}
+	}
+	// B is orthonormal
+	for i := range a {
+		for j := range a {
+			var dot float64
+			for k := range a {
+				dot += vectors[k][i] * vectors[k][j]
+			}
+			expected := 0.0
+			if i == j {
+				expected = 1
+			}
+			if math.Abs(dot-expected) > 1e-9 {
+				t.Errorf("eigenvectors %v and %v have dot product %v", i, j, dot)
+			}
+		}
+	}
+}
+
+// quadratic earns the negative weighted squared distance of prices from 0.5, within [0, 1]
+type quadratic struct {
+	weights []float64
+}
+
+func (q quadratic) Evaluate(prices []float64) (float64, error) {
+	var rev float64
+	for i, p := range prices {
+		rev -= q.weights[i] * (p - 0.5) * (p - 0.5)
+	}
+	return rev, nil
+}
+
+func (q quadratic) Bounds() [][]float64 {
+	bounds := make([][]float64, len(q.weights))
+	for i := range bounds {
+		bounds[i] = []float64{0, 1}
+	}
+	return bounds
+}
+
+func (q quadratic) IsValid(prices []float64) bool {
+	for _, p := range prices {
+		if p < 0 || p > 1 {
+			return false
+		}
+	}
+	return len(prices) == len(q.weights)
+}
+
+func Test_initEvaluatesMean(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	es := NewES(5, DefaultOptions(), pr)
+	prices, rev := es.Best()
+	if !pr.IsValid(prices) || math.IsInf(rev, -1) {
+		t.Fatalf("expected the starting mean as the best, actual %v with %v", prices, rev)
+	}
+	for i := range prices {
+		if math.Abs(prices[i]-(0.01+es.mean[i]*9.99)) > 1e-9 {
+			t.Errorf("best prices %v are not the mean %v", prices, es.mean)
+		}
+	}
+	if expected, _ := pr.Evaluate(prices); rev != expected {
+		t.Errorf("best revenue %v, expected %v", rev, expected)
+	}
+}
+
+func Test_stepSizeAdaptation(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Restart = NoRestart
+
+	// a consistent direction of improvement lengthens the evolution path, increasing the step size
+	opts.Sigma = 0.001
+	es := NewES(4, opts, quadratic{[]float64{1, 1, 1, 1}})
+	es.mean = []float64{0.1, 0.1, 0.1, 0.1}
+	for i := 0; i < 10; i++ {
+		es.Step()
+	}
+	if es.sigma <= 0.001 {
+		t.Errorf("step size %v did not grow while far from the optimum", es.sigma)
+	}
+
+	// close to the optimum the step size shrinks, and the mean converges on it
+	opts.Sigma = 0.3
+	es = NewES(4, opts, quadratic{[]float64{1, 1, 1, 1}})
+	for i := 0; i < 200; i++ {
+		es.Step()
+	}
+	if es.sigma > 1e-3 {
+		t.Errorf("step size %v did not shrink at the optimum", es.sigma)
+	}
+	for _, m := range es.mean {
+		if math.Abs(m-0.5) > 1e-3 {
+			t.Errorf("mean %v did not converge on 0.5", es.mean)
+			break
+		}
+	}
+}
+
+func Test_covarianceAdaptation(t *testing.T) {
+	// revenue is 100 times more sensitive to the second price, so the distribution narrows along it
+	opts := DefaultOptions()
+	opts.Restart = NoRestart
+	es := NewES(2, opts, quadratic{[]float64{1, 100}})
+	for i := 0; i < 60; i++ {
+		es.Step()
+	}
+	if ratio := es.c[0][0] / es.c[1][1]; ratio < 10 {
+		t.Errorf("expected variance of the first price to grow relative to the second, ratio %v", ratio)
+	}
+	if math.Abs(es.c[0][1]-es.c[1][0]) > 0 {
+		t.Errorf("covariance matrix not symmetric : %v", es.c)
+	}
+}
+
+func Test_restarts(t *testing.T) {
+	for _, r := range []Restart{IPOP, BIPOP} {
+		opts := DefaultOptions()
+		opts.Restart, opts.MaxRestarts = r, 3
+		es := NewES(2, opts, quadratic{[]float64{1, 1}})
+		for i := 0; i < 2000 && es.Restarts < 3; i++ {
+			es.Step()
+		}
+		if es.Restarts != 3 {
+			t.Fatalf("%v : expected 3 restarts once converged, actual %v", r, es.Restarts)
+		}
+		if es.largeLambda < 2*es.defaultLambda {
+			t.Errorf("%v : large population %v not doubled from %v", r, es.largeLambda, es.defaultLambda)
+		}
+		if r == IPOP && es.Lambda != es.defaultLambda*8 {
+			t.Errorf("IPOP : population %v, expected %v", es.Lambda, es.defaultLambda*8)
+		}
+	}
+}
+
+func Test_improvesPricing(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+	es := NewES(10, DefaultOptions(), pr)
+	_, initial := es.Best()
+	for i := 0; i < 100; i++ {
+		es.Step()
+	}
+	if prices, rev := es.Best(); rev <= initial || !pr.IsValid(prices) {
+		t.Errorf("revenue %v did not improve on %v, or prices invalid : %v", rev, initial, prices)
+	}
+}
@@ -0,0 +1,66 @@
+package cmaes
+
+import "strconv"
+
+// Restart determines how the search restarts once it has converged
+type Restart int
+
+const (
+	// NoRestart continues sampling from the converged distribution
+	NoRestart Restart = iota
+	// IPOP restarts from a random mean with double the population size (Auger & Hansen)
+	IPOP
+	// BIPOP alternates between IPOP restarts and restarts with small populations and step sizes,
+	// choosing whichever regime has used fewer evaluations (Hansen)
+	BIPOP
+)
+
+// Restarts lists every restart strategy, for comparisons
+var Restarts = []Restart{NoRestart, IPOP, BIPOP}
+
+func (r Restart) String() string {
+	switch r {
+	case NoRestart:
+		return "none"
+	case IPOP:
+		return "ipop"
+	case BIPOP:
+		return "bipop"
+	}
+	return "unknown"
+}
+
+// Options configures an ES
+type Options struct {
+	Sigma       float64 // initial step size, as a fraction of each good's bound range
+	Lambda      int     // initial population size, 0 uses 4 + 3ln(number of goods)
+	Restart     Restart
+	MaxRestarts int     // restarts before the search continues from its last distribution
+	TolX        float64 // converged once the step size is this fraction of the bound range
+	TolFun      float64 // converged once the best revenue of recent generations varies by this fraction
+}
+
+// DefaultOptions returns CMA-ES parameters suited to the pricing problem, restarting with IPOP
+func DefaultOptions() Options {
+	return Options{
+		Sigma:       0.3,
+		Lambda:      0,
+		Restart:     IPOP,
+		MaxRestarts: 9,
+		TolX:        1e-11,
+		TolFun:      1e-9,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"sigma":   strconv.FormatFloat(o.Sigma, 'f', -1, 64),
+		"lambda":  strconv.Itoa(o.Lambda),
+		"restart": o.Restart.String(),
+	}
+	if o.Restart != NoRestart {
+		params["maxRestarts"] = strconv.Itoa(o.MaxRestarts)
+	}
+	return params
+}
@@ -0,0 +1,275 @@
+package de
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Individual is a single candidate set of prices and its revenue
+type Individual struct {
+	prices  []float64
+	Revenue float64
+}
+
+// Adaptation is the mean F and CR of CurrentToPBest after one step
+type Adaptation struct {
+	F, CR float64
+}
+
+// Population models the individuals of a differential evolution search along with the current best prices and revenue
+type Population struct {
+	Individuals    []Individual
+	BestPrices     []float64
+	BestRevenue    float64
+	Adaptations    []Adaptation // mean F and CR of each step since Init, CurrentToPBest only
+	numGoods       int
+	numIndividuals int
+	problem        pp.Problem
+	opts           Options
+	muF, muCR      float64     // adapted means of F and CR
+	archive        [][]float64 // parents replaced by better trials, CurrentToPBest only
+}
+
+// NewPopulation generates a new population of random Individuals, evolving according to opts
+// numIndividuals below 4 is raised to 4, as every strategy needs 3 individuals other than the one it mutates
+// if pr is nil, the population is left empty until Init is called
+func NewPopulation(numGoods, numIndividuals int, opts Options, pr pp.Problem) *Population {
+	pop := new(Population)
+	pop.numGoods = numGoods
+	pop.numIndividuals = numIndividuals
+	if pop.numIndividuals < 4 {
+		pop.numIndividuals = 4 // the target and 3 distinct others
+	}
+	pop.opts = opts
+	if pr != nil {
+		pop.Init(pr)
+	}
+	return pop
+}
+
+// Init populates the population with new random Individuals for problem pr, discarding any previous progress
+func (pop *Population) Init(pr pp.Problem) {
+	pop.problem = pr
+	pop.numGoods = len(pr.Bounds())
+	pop.Individuals = make([]Individual, pop.numIndividuals)
+	pop.Adaptations = []Adaptation{}
+	pop.archive = [][]float64{}
+	pop.muF, pop.muCR = pop.opts.F, pop.opts.CR
+	pop.BestRevenue = 0
+
+	for i := 0; i < pop.numIndividuals; i++ {
+		prices := randomPrices(pr)
+		rev, _ := pr.Evaluate(prices)
+		pop.Individuals[i] = Individual{prices, rev}
+		if i == 0 || rev > pop.BestRevenue {
+			pop.BestRevenue = rev
+			pop.BestPrices = append([]float64{}, prices...)
+		}
+	}
+}
+
+// Options returns the parameters of the population
+func (pop *Population) Options() Options {
+	return pop.opts
+}
+
+// Params lists the parameters of the population, for recording alongside results
+func (pop *Population) Params() map[string]string {
+	params := pop.opts.Params()
+	params["population"] = strconv.Itoa(pop.numIndividuals)
+	return params
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (pop *Population) Best() ([]float64, float64) {
+	return append([]float64{}, pop.BestPrices...), pop.BestRevenue
+}
+
+// Step creates a trial for every individual by mutation and binomial crossover,
+// and replaces each individual whose trial earns at least as much revenue
+func (pop *Population) Step() {
+	n := len(pop.Individuals)
+	trials := make([]Individual, n)
+	fs, crs := make([]float64, n), make([]float64, n)
+	for i := 0; i < n; i++ {
+		fs[i], crs[i] = pop.opts.F, pop.opts.CR
+		if pop.opts.Strategy == CurrentToPBest {
+			fs[i], crs[i] = pop.sampleF(), pop.sampleCR()
+		}
+		trial := pop.crossover(pop.Individuals[i].prices, pop.mutant(i, fs[i]), crs[i])
+		trials[i] = Individual{trial, 0}
+		if pop.problem.IsValid(trial) {
+			trials[i].Revenue, _ = pop.problem.Evaluate(trial)
+		} else {
+			trials[i].Revenue = math.Inf(-1)
+		}
+	}
+
+	// selection
+	var successF, successCR []float64
+	for i := 0; i < n; i++ {
+		if trials[i].Revenue < pop.Individuals[i].Revenue {
+			continue
+		}
+		if trials[i].Revenue > pop.Individuals[i].Revenue {
+			successF = append(successF, fs[i])
+			successCR = append(successCR, crs[i])
+			if pop.opts.Strategy == CurrentToPBest {
+				pop.archive = append(pop.archive, pop.Individuals[i].prices)
+			}
+		}
+		pop.Individuals[i] = trials[i]
+		if trials[i].Revenue > pop.BestRevenue {
+			pop.BestRevenue = trials[i].Revenue
+			pop.BestPrices = append([]float64{}, trials[i].prices...)
+		}
+	}
+
+	if pop.opts.Strategy == CurrentToPBest {
+		pop.adapt(successF, successCR)
+	}
+}
+
+// mutant creates the mutant vector for individual i with differential weight f, repaired to within bounds
+func (pop *Population) mutant(i int, f float64) []float64 {
+	x := pop.Individuals
+	v := make([]float64, pop.numGoods)
+	switch pop.opts.Strategy {
+	case BestOneBin:
+		r := distinct(len(x), 2, i)
+		for d := range v {
+			v[d] = pop.BestPrices[d] + f*(x[r[0]].prices[d]-x[r[1]].prices[d])
+		}
+	case CurrentToPBest:
+		pBest := pop.pBest()
+		r1 := distinct(len(x), 1, i)[0]
+		r2 := rand.Intn(len(x) + len(pop.archive))
+		for r2 == i || r2 == r1 {
+			r2 = rand.Intn(len(x) + len(pop.archive))
+		}
+		var xr2 []float64 // chosen from the population and archive together
+		if r2 < len(x) {
+			xr2 = x[r2].prices
+		} else {
+			xr2 = pop.archive[r2-len(x)]
+		}
+		for d := range v {
+			v[d] = x[i].prices[d] + f*(pBest[d]-x[i].prices[d]) + f*(x[r1].prices[d]-xr2[d])
+		}
+	default:
+		r := distinct(len(x), 3, i)
+		for d := range v {
+			v[d] = x[r[0]].prices[d] + f*(x[r[1]].prices[d]-x[r[2]].prices[d])
+		}
+	}
+	return pop.repair(v, x[i].prices)
+}
+
+// crossover takes each price from the mutant with probability cr, and at least one price, otherwise from the target
+func (pop *Population) crossover(target, mutant []float64, cr float64) []float64 {
+	trial := append([]float64{}, target...)
+	jRand := rand.Intn(len(trial))
+	for d := range trial {
+		if d == jRand || rand.Float64() < cr {
+			trial[d] = mutant[d]
+		}
+	}
+	return trial
+}
+
+// repair moves any price outside its bounds to halfway between the bound and the target's price
+func (pop *Population) repair(v, target []float64) []float64 {
+	bounds := pop.problem.Bounds()
+	for d := range v {
+		if v[d] < bounds[d][0] {
+			v[d] = (bounds[d][0] + target[d]) / 2
+		} else if v[d] > bounds[d][1] {
+			v[d] = (bounds[d][1] + target[d]) / 2
+		}
+	}
+	return v
+}
+
+// pBest returns the prices of a random individual among the best P of the population
+func (pop *Population) pBest() []float64 {
+	sorted := make([]Individual, len(pop.Individuals))
+	copy(sorted, pop.Individuals)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Revenue > sorted[j].Revenue
+	})
+	top := int(math.Max(1, math.Round(pop.opts.P*float64(len(sorted)))))
+	return sorted[rand.Intn(top)].prices
+}
+
+// sampleF draws F from a Cauchy distribution about muF, redrawn until positive and truncated to 1
+func (pop *Population) sampleF() float64 {
+	f := 0.0
+	for f <= 0 {
+		f = pop.muF + 0.1*math.Tan(math.Pi*(rand.Float64()-0.5))
+	}
+	return math.Min(f, 1)
+}
+
+// sampleCR draws CR from a normal distribution about muCR, truncated to [0, 1]
+func (pop *Population) sampleCR() float64 {
+	return math.Min(math.Max(pop.muCR+0.1*rand.NormFloat64(), 0), 1)
+}
+
+// adapt moves muF towards the Lehmer mean and muCR towards the mean of the successful F and CR of the step,
+// and trims the archive to the size of the population
+func (pop *Population) adapt(successF, successCR []float64) {
+	if len(successF) > 0 {
+		var sumF, sumF2, sumCR float64
+		for k := range successF {
+			sumF += successF[k]
+			sumF2 += successF[k] * successF[k]
+			sumCR += successCR[k]
+		}
+		pop.muF = (1-pop.opts.C)*pop.muF + pop.opts.C*sumF2/sumF
+		pop.muCR = (1-pop.opts.C)*pop.muCR + pop.opts.C*sumCR/float64(len(successCR))
+	}
+	for len(pop.archive) > len(pop.Individuals) {
+		r := rand.Intn(len(pop.archive))
+		pop.archive[r] = pop.archive[len(pop.archive)-1]
+		pop.archive = pop.archive[:len(pop.archive)-1]
+	}
+	pop.Adaptations = append(pop.Adaptations, Adaptation{pop.muF, pop.muCR})
+}
+
+// distinct returns k different random indices below n, none of which are exclude
+func distinct(n, k, exclude int) []int {
+	chosen := make([]int, 0, k)
+	for len(chosen) < k {
+		r := rand.Intn(n)
+		if r == exclude {
+			continue
+		}
+		unique := true
+		for _, c := range chosen {
+			if c == r {
+				unique = false
+				break
+			}
+		}
+		if unique {
+			chosen = append(chosen, r)
+		}
+	}
+	return chosen
+}
+
+// randomPrices generates random prices within the bounds of the problem
+func randomPrices(pr pp.Problem) []float64 {
+	bounds := pr.Bounds()
+	prices := make([]float64, len(bounds))
+	for !pr.IsValid(prices) { // while not valid, select prices at random
+		for i := range prices {
+			prices[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+		}
+	}
+	return prices
+}
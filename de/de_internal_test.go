@@ -0,0 +1,112 @@
+package de
+
+import (
+	"math"
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_repair(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(2, 0, false)
+	pop := NewPopulation(2, 4, DefaultOptions(), pr)
+	lower, upper := pr.Bounds()[0][0], pr.Bounds()[1][1]
+
+	repaired := pop.repair([]float64{lower - 5, upper + 5}, []float64{lower + 2, upper - 2})
+	if math.Abs(repaired[0]-(lower+1)) > 1e-9 || math.Abs(repaired[1]-(upper-1)) > 1e-9 {
+		t.Errorf("prices not repaired halfway to target : %v", repaired)
+	}
+}
+
+func Test_strategies(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+	for _, s := range Strategies {
+		opts := DefaultOptions()
+		opts.Strategy = s
+		pop := NewPopulation(10, 20, opts, pr)
+		_, initial := pop.Best()
+		for i := 0; i < 100; i++ {
+			pop.Step()
+			if len(pop.archive) > len(pop.Individuals) {
+				t.Fatalf("%v : archive grew to %v, more than the population", s, len(pop.archive))
+			}
+			for _, ind := range pop.Individuals {
+				if !pr.IsValid(ind.prices) || ind.Revenue > pop.BestRevenue {
+					t.Fatalf("%v : invalid individual or best revenue not kept : %v", s, ind)
+				}
+			}
+		}
+		if s != CurrentToPBest && len(pop.archive) > 0 {
+			t.Errorf("%v : archive used outside of current-to-pbest", s)
+		}
+		if _, rev := pop.Best(); rev <= initial {
+			t.Errorf("%v : revenue %v did not improve on %v", s, rev, initial)
+		}
+	}
+}
+
+func Test_adapt(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(2, 0, false)
+	opts := DefaultOptions()
+	opts.Strategy = CurrentToPBest
+	pop := NewPopulation(2, 4, opts, pr)
+
+	// muF moves towards the Lehmer mean of the successful F, muCR towards the arithmetic mean of CR
+	pop.archive = [][]float64{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6}}
+	pop.adapt([]float64{0.5, 1}, []float64{0.2, 0.4})
+	lehmer := (0.25 + 1) / 1.5
+	if math.Abs(pop.muF-(0.9*0.5+0.1*lehmer)) > 1e-12 || math.Abs(pop.muCR-(0.9*0.9+0.1*0.3)) > 1e-12 {
+		t.Errorf("unexpected means F %v, CR %v", pop.muF, pop.muCR)
+	}
+	if len(pop.archive) != 4 {
+		t.Errorf("archive not trimmed to the population size : %v", len(pop.archive))
+	}
+
+	// without successful trials the means are kept
+	muF, muCR := pop.muF, pop.muCR
+	pop.adapt(nil, nil)
+	if pop.muF != muF || pop.muCR != muCR || len(pop.Adaptations) != 2 || pop.Adaptations[1] != (Adaptation{muF, muCR}) {
+		t.Errorf("means changed without successes, or not recorded : %v", pop.Adaptations)
+	}
+
+	for i := 0; i < 1000; i++ {
+		if f, cr := pop.sampleF(), pop.sampleCR(); f <= 0 || f > 1 || cr < 0 || cr > 1 {
+			t.Fatalf("sampled F %v or CR %v out of range", f, cr)
+		}
+	}
+}
+
+// counting counts the revenues evaluated on the wrapped problem
+type counting struct {
+	pp.Problem
+	evaluations int
+}
+
+func (c *counting) Evaluate(prices []float64) (float64, error) {
+	c.evaluations++
+	return c.Problem.Evaluate(prices)
+}
+
+func Test_smallPopulation(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	for _, size := range []int{0, 1, 3} {
+		for _, s := range Strategies {
+			opts := DefaultOptions()
+			opts.Strategy = s
+			c := &counting{Problem: pr}
+			pop := NewPopulation(5, size, opts, c)
+			if len(pop.Individuals) != 4 || pop.Params()["population"] != "4" {
+				t.Errorf("%v : population of %v raised to %v individuals", s, size, len(pop.Individuals))
+			}
+			// every step evaluates a trial for each individual, rather than doing nothing
+			pop.Step()
+			if c.evaluations <= 4 {
+				t.Errorf("%v : population of %v made %v evaluations in Init and one step", s, size, c.evaluations)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package de
+
+import "strconv"
+
+// Strategy determines how mutant vectors are created
+type Strategy int
+
+const (
+	// RandOneBin is DE/rand/1/bin, mutant = x_r1 + F * (x_r2 - x_r3)
+	RandOneBin Strategy = iota
+	// BestOneBin is DE/best/1/bin, mutant = x_best + F * (x_r1 - x_r2)
+	BestOneBin
+	// CurrentToPBest is JADE's DE/current-to-pbest/1 with an archive, mutant = x_i + F * (x_pbest - x_i) + F * (x_r1 - x_r2),
+	// where x_pbest is one of the best P of the population, and F and CR of each individual adapt to successful values (Zhang & Sanderson)
+	CurrentToPBest
+)
+
+// Strategies lists every mutation strategy, for comparisons
+var Strategies = []Strategy{RandOneBin, BestOneBin, CurrentToPBest}
+
+func (s Strategy) String() string {
+	switch s {
+	case RandOneBin:
+		return "rand/1/bin"
+	case BestOneBin:
+		return "best/1/bin"
+	case CurrentToPBest:
+		return "current-to-pbest/1"
+	}
+	return "unknown"
+}
+
+// Options configures a Population
+type Options struct {
+	Strategy Strategy
+	F        float64 // differential weight, the starting mean of F under CurrentToPBest
+	CR       float64 // crossover probability of each price, the starting mean of CR under CurrentToPBest
+	P        float64 // fraction of the best individuals x_pbest is chosen from, CurrentToPBest only
+	C        float64 // rate of adaptation of the means of F and CR, CurrentToPBest only
+}
+
+// DefaultOptions returns commonly used DE parameters, F = 0.5 and CR = 0.9, with JADE's recommended p = 0.05 and c = 0.1
+func DefaultOptions() Options {
+	return Options{
+		Strategy: RandOneBin,
+		F:        0.5,
+		CR:       0.9,
+		P:        0.05,
+		C:        0.1,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"strategy": o.Strategy.String(),
+		"F":        strconv.FormatFloat(o.F, 'f', -1, 64),
+		"CR":       strconv.FormatFloat(o.CR, 'f', -1, 64),
+	}
+	if o.Strategy == CurrentToPBest {
+		params["p"] = strconv.FormatFloat(o.P, 'f', -1, 64)
+		params["c"] = strconv.FormatFloat(o.C, 'f', -1, 64)
+	}
+	return params
+}
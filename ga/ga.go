@@ -0,0 +1,220 @@
+package ga
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Individual is a single candidate set of prices and its revenue
+type Individual struct {
+	prices  []float64
+	Revenue float64
+}
+
+// Population models the individuals of a genetic algorithm along with the current best prices and revenue
+type Population struct {
+	Individuals    []Individual // sorted by the highest revenue first
+	BestPrices     []float64
+	BestRevenue    float64
+	numGoods       int
+	numIndividuals int
+	problem        pp.Problem
+	opts           Options
+}
+
+// NewPopulation generates a new population of random Individuals, evolving according to opts
+// numIndividuals below 2 is raised to 2, so each generation holds a child besides the best individual
+// if pr is nil, the population is left empty until Init is called
+func NewPopulation(numGoods, numIndividuals int, opts Options, pr pp.Problem) *Population {
+	pop := new(Population)
+	pop.numGoods = numGoods
+	pop.numIndividuals = numIndividuals
+	if pop.numIndividuals < 2 {
+		pop.numIndividuals = 2 // a survivor and a child
+	}
+	pop.opts = opts
+	if pr != nil {
+		pop.Init(pr)
+	}
+	return pop
+}
+
+// Init populates the population with new random Individuals for problem pr, discarding any previous progress
+func (pop *Population) Init(pr pp.Problem) {
+	pop.problem = pr
+	pop.numGoods = len(pr.Bounds())
+	pop.Individuals = make([]Individual, pop.numIndividuals)
+	for i := 0; i < pop.numIndividuals; i++ {
+		prices := randomPrices(pr)
+		rev, _ := pr.Evaluate(prices)
+		pop.Individuals[i] = Individual{prices, rev}
+	}
+	pop.sort()
+	pop.BestPrices = append([]float64{}, pop.Individuals[0].prices...)
+	pop.BestRevenue = pop.Individuals[0].Revenue
+}
+
+// Options returns the parameters of the population
+func (pop *Population) Options() Options {
+	return pop.opts
+}
+
+// Params lists the parameters of the population, for recording alongside results
+func (pop *Population) Params() map[string]string {
+	params := pop.opts.Params()
+	params["population"] = strconv.Itoa(pop.numIndividuals)
+	return params
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (pop *Population) Best() ([]float64, float64) {
+	return append([]float64{}, pop.BestPrices...), pop.BestRevenue
+}
+
+// Step replaces the population with a new generation
+// the Elitism best individuals survive unchanged, the rest are children of selected parents, crossed and mutated
+// at most all but one individual survive, so every generation evaluates a child
+func (pop *Population) Step() {
+	n := len(pop.Individuals)
+	elites := pop.opts.Elitism
+	if elites > n-1 {
+		elites = n - 1
+	}
+	next := make([]Individual, 0, n)
+	next = append(next, pop.Individuals[:elites]...)
+
+	for len(next) < n {
+		a, b := pop.Individuals[pop.selectParent()], pop.Individuals[pop.selectParent()]
+		childA, childB := append([]float64{}, a.prices...), append([]float64{}, b.prices...)
+		if rand.Float64() < pop.opts.CrossoverRate {
+			childA, childB = pop.crossover(a.prices, b.prices)
+		}
+		for _, child := range [][]float64{childA, childB} {
+			if len(next) == n {
+				break
+			}
+			pop.mutate(child)
+			if !pop.problem.IsValid(child) {
+				child = randomPrices(pop.problem)
+			}
+			rev, _ := pop.problem.Evaluate(child)
+			next = append(next, Individual{child, rev})
+		}
+	}
+
+	pop.Individuals = next
+	pop.sort()
+	if pop.Individuals[0].Revenue > pop.BestRevenue {
+		pop.BestRevenue = pop.Individuals[0].Revenue
+		pop.BestPrices = append([]float64{}, pop.Individuals[0].prices...)
+	}
+}
+
+// selectParent chooses the index of a parent according to the selection scheme
+func (pop *Population) selectParent() int {
+	n := len(pop.Individuals)
+	if pop.opts.Selection == RankSelection {
+		// linear ranking, the individual ranked i from the worst (0) is chosen with probability
+		// (2 - s) / n + 2i(s - 1) / (n(n - 1))
+		s := pop.opts.RankPressure
+		r := rand.Float64()
+		var cumulative float64
+		for i := n - 1; i >= 0; i-- {
+			rank := float64(n - 1 - i) // individuals are sorted best first
+			p := (2 - s) / float64(n)
+			if n > 1 {
+				p += 2 * rank * (s - 1) / float64(n*(n-1))
+			}
+			cumulative += p
+			if r < cumulative {
+				return i
+			}
+		}
+		return 0
+	}
+
+	best := rand.Intn(n)
+	for k := 1; k < pop.opts.TournamentSize; k++ {
+		if c := rand.Intn(n); c < best { // individuals are sorted best first
+			best = c
+		}
+	}
+	return best
+}
+
+// crossover combines two parents into two children within bounds
+func (pop *Population) crossover(a, b []float64) ([]float64, []float64) {
+	bounds := pop.problem.Bounds()
+	childA, childB := make([]float64, len(a)), make([]float64, len(b))
+	for i := range a {
+		switch pop.opts.Crossover {
+		case BLXAlpha:
+			low, high := math.Min(a[i], b[i]), math.Max(a[i], b[i])
+			extension := pop.opts.Alpha * (high - low)
+			childA[i] = low - extension + rand.Float64()*(high-low+2*extension)
+			childB[i] = low - extension + rand.Float64()*(high-low+2*extension)
+		default:
+			childA[i], childB[i] = a[i], b[i]
+			if rand.Float64() < 0.5 {
+				u := rand.Float64()
+				beta := math.Pow(2*u, 1/(pop.opts.Eta+1))
+				if u > 0.5 {
+					beta = math.Pow(1/(2*(1-u)), 1/(pop.opts.Eta+1))
+				}
+				childA[i] = 0.5 * ((1+beta)*a[i] + (1-beta)*b[i])
+				childB[i] = 0.5 * ((1-beta)*a[i] + (1+beta)*b[i])
+			}
+		}
+		childA[i] = math.Min(math.Max(childA[i], bounds[i][0]), bounds[i][1])
+		childB[i] = math.Min(math.Max(childB[i], bounds[i][0]), bounds[i][1])
+	}
+	return childA, childB
+}
+
+// mutate applies Deb's polynomial mutation to each price of child with probability MutationRate
+func (pop *Population) mutate(child []float64) {
+	bounds := pop.problem.Bounds()
+	rate := pop.opts.MutationRate
+	if rate <= 0 {
+		rate = 1 / float64(len(child))
+	}
+	power := 1 / (pop.opts.MutationEta + 1)
+	for i := range child {
+		if rand.Float64() >= rate {
+			continue
+		}
+		width := bounds[i][1] - bounds[i][0]
+		lower, upper := (child[i]-bounds[i][0])/width, (bounds[i][1]-child[i])/width
+		r := rand.Float64()
+		var delta float64
+		if r < 0.5 {
+			delta = math.Pow(2*r+(1-2*r)*math.Pow(1-lower, pop.opts.MutationEta+1), power) - 1
+		} else {
+			delta = 1 - math.Pow(2*(1-r)+2*(r-0.5)*math.Pow(1-upper, pop.opts.MutationEta+1), power)
+		}
+		child[i] = math.Min(math.Max(child[i]+delta*width, bounds[i][0]), bounds[i][1])
+	}
+}
+
+// sort orders the individuals by the highest revenue first
+func (pop *Population) sort() {
+	sort.Slice(pop.Individuals, func(i, j int) bool {
+		return pop.Individuals[i].Revenue > pop.Individuals[j].Revenue
+	})
+}
+
+// randomPrices generates random prices within the bounds of the problem
+func randomPrices(pr pp.Problem) []float64 {
+	bounds := pr.Bounds()
+	prices := make([]float64, len(bounds))
+	for !pr.IsValid(prices) { // while not valid, select prices at random
+		for i := range prices {
+			prices[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+		}
+	}
+	return prices
+}
@@ -0,0 +1,197 @@
+package ga
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_crossoverWithinBounds(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	for _, c := range []Crossover{SBX, BLXAlpha} {
+		opts := DefaultOptions()
+		opts.Crossover = c
+		pop := NewPopulation(5, 10, opts, pr)
+		for i := 0; i < 100; i++ {
+			childA, childB := pop.crossover(pop.Individuals[0].prices, pop.Individuals[9].prices)
+			pop.mutate(childA)
+			if !pr.IsValid(childA) || !pr.IsValid(childB) {
+				t.Fatalf("%v children outside bounds : %v %v", c, childA, childB)
+			}
+		}
+	}
+}
+
+func Test_selectionPressure(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	tests := []struct {
+		selection   Selection
+		best, worst float64 // probability of selecting the best and worst of 10 individuals
+	}{
+		{TournamentSelection, 1 - 0.9*0.9, 0.1 * 0.1}, // the best of 2 random individuals
+		{RankSelection, 1.5 / 10, 0.5 / 10},           // (2 - s) / n for the worst, s / n for the best
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Selection = tt.selection
+		pop := NewPopulation(5, 10, opts, pr)
+		counts := make([]int, 10)
+		draws := 20000
+		for i := 0; i < draws; i++ {
+			counts[pop.selectParent()]++
+		}
+		best, worst := float64(counts[0])/float64(draws), float64(counts[9])/float64(draws)
+		if math.Abs(best-tt.best) > 0.02 || math.Abs(worst-tt.worst) > 0.02 {
+			t.Errorf("%v selection chose the best %v and worst %v of the time, expected %v and %v", tt.selection, best, worst, tt.best, tt.worst)
+		}
+		for i := 1; i < 10; i++ {
+			if counts[i] > counts[i-1]+draws/50 {
+				t.Errorf("%v selection chose rank %v more than rank %v : %v", tt.selection, i, i-1, counts)
+			}
+		}
+	}
+}
+
+// counting counts the revenues evaluated on the wrapped problem
+type counting struct {
+	pp.Problem
+	evaluations int
+}
+
+func (c *counting) Evaluate(prices []float64) (float64, error) {
+	c.evaluations++
+	return c.Problem.Evaluate(prices)
+}
+
+func Test_populationSize(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	tests := []struct {
+		individuals, elitism int
+		size, children       int // individuals kept, and children evaluated each step
+	}{
+		{0, 2, 2, 1},
+		{1, 0, 2, 2},
+		{10, 2, 10, 8},
+		{10, 10, 10, 1}, // all but one survive
+	}
+	for _, tt := range tests {
+		opts := DefaultOptions()
+		opts.Elitism = tt.elitism
+		c := &counting{Problem: pr}
+		pop := NewPopulation(5, tt.individuals, opts, c)
+		if len(pop.Individuals) != tt.size || c.evaluations != tt.size {
+			t.Errorf("population of %v : %v individuals, %v evaluations", tt.individuals, len(pop.Individuals), c.evaluations)
+		}
+		pop.Step()
+		if len(pop.Individuals) != tt.size || c.evaluations != tt.size+tt.children {
+			t.Errorf("population of %v, elitism %v : %v children evaluated, expected %v", tt.individuals, tt.elitism, c.evaluations-tt.size, tt.children)
+		}
+	}
+}
+
+func Test_elitism(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	opts := DefaultOptions()
+	opts.Elitism = 3
+	pop := NewPopulation(5, 10, opts, pr)
+	for step := 0; step < 20; step++ {
+		elites := map[string]float64{}
+		for _, ind := range pop.Individuals[:3] {
+			elites[fmt.Sprint(ind.prices)] = ind.Revenue
+		}
+		pop.Step()
+		survived := 0
+		for _, ind := range pop.Individuals {
+			if rev, ok := elites[fmt.Sprint(ind.prices)]; ok && rev == ind.Revenue {
+				survived++
+			}
+		}
+		if survived < 3 {
+			t.Fatalf("step %v : %v of the 3 elites survived", step, survived)
+		}
+	}
+}
+
+func Test_step(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+	for _, elitism := range []int{0, 2} {
+		opts := DefaultOptions()
+		opts.Elitism = elitism
+		pop := NewPopulation(10, 20, opts, pr)
+		_, initial := pop.Best()
+		for step := 0; step < 100; step++ {
+			_, before := pop.Best()
+			pop.Step()
+			prices, rev := pop.Best()
+			if rev < before || rev < pop.Individuals[0].Revenue {
+				t.Fatalf("elitism %v, step %v : best %v fell from %v, or is below the population's %v", elitism, step, rev, before, pop.Individuals[0].Revenue)
+			}
+			if check, _ := pr.Evaluate(prices); check != rev {
+				t.Fatalf("elitism %v : best revenue %v, prices earn %v", elitism, rev, check)
+			}
+			for i, ind := range pop.Individuals {
+				if !pr.IsValid(ind.prices) || (i > 0 && ind.Revenue > pop.Individuals[i-1].Revenue) {
+					t.Fatalf("elitism %v : individual %v invalid or out of order", elitism, i)
+				}
+			}
+			// with elites, the population always holds the best found
+			if elitism > 0 && pop.Individuals[0].Revenue != rev {
+				t.Fatalf("elitism %v : population's best %v, best found %v", elitism, pop.Individuals[0].Revenue, rev)
+			}
+		}
+		if _, rev := pop.Best(); rev <= initial {
+			t.Errorf("elitism %v : revenue %v did not improve on %v", elitism, rev, initial)
+		}
+	}
+}
+
+func Test_mutation(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(4, 0, false)
+	bounds := pr.Bounds()
+	opts := DefaultOptions()
+	opts.MutationRate = 1
+	pop := NewPopulation(4, 10, opts, pr)
+
+	// every price moves, staying within bounds, mostly by a small fraction of the range
+	var shift float64
+	trials := 2000
+	for k := 0; k < trials; k++ {
+		parent := []float64{bounds[0][0], bounds[1][1], (bounds[2][0] + bounds[2][1]) / 2, bounds[3][0] + 0.1}
+		child := append([]float64{}, parent...)
+		pop.mutate(child)
+		for i := range child {
+			if child[i] < bounds[i][0] || child[i] > bounds[i][1] {
+				t.Fatalf("mutated price %v to %v, outside %v", i, child[i], bounds[i])
+			}
+			shift += math.Abs(child[i]-parent[i]) / (bounds[i][1] - bounds[i][0]) / float64(4*trials)
+		}
+	}
+	if shift <= 0 || shift > 0.1 {
+		t.Errorf("mean shift %v of the range, expected small with eta %v", shift, opts.MutationEta)
+	}
+
+	// a rate of 0 mutates one price per child on average
+	pop.opts.MutationRate = 0
+	changed := 0
+	for k := 0; k < trials; k++ {
+		parent := []float64{2, 4, 6, 8}
+		child := append([]float64{}, parent...)
+		pop.mutate(child)
+		for i := range child {
+			if child[i] != parent[i] {
+				changed++
+			}
+		}
+	}
+	if mean := float64(changed) / float64(trials); math.Abs(mean-1) > 0.1 {
+		t.Errorf("mutated %v prices per child, expected 1", mean)
+	}
+}
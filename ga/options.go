@@ -0,0 +1,96 @@
+package ga
+
+import "strconv"
+
+// Selection determines how parents are chosen
+type Selection int
+
+const (
+	// TournamentSelection picks the best of TournamentSize random individuals
+	TournamentSelection Selection = iota
+	// RankSelection picks individuals with probability linear in their rank, the best being RankPressure times as likely as average
+	RankSelection
+)
+
+func (s Selection) String() string {
+	switch s {
+	case TournamentSelection:
+		return "tournament"
+	case RankSelection:
+		return "rank"
+	}
+	return "unknown"
+}
+
+// Crossover determines how two parents are combined into two children
+type Crossover int
+
+const (
+	// SBX is simulated binary crossover, children are spread about the parents according to the distribution index Eta (Deb & Agrawal)
+	SBX Crossover = iota
+	// BLXAlpha draws each child price uniformly from the range of the parents' prices, extended by Alpha of its width either side
+	BLXAlpha
+)
+
+func (c Crossover) String() string {
+	switch c {
+	case SBX:
+		return "sbx"
+	case BLXAlpha:
+		return "blx-alpha"
+	}
+	return "unknown"
+}
+
+// Options configures a Population
+type Options struct {
+	Selection      Selection
+	TournamentSize int     // individuals in each tournament, TournamentSelection only
+	RankPressure   float64 // expected selections of the best individual, between 1 and 2, RankSelection only
+	Crossover      Crossover
+	CrossoverRate  float64 // probability of a pair of parents being crossed, otherwise they are copied
+	Eta            float64 // SBX distribution index, higher values keep children closer to their parents
+	Alpha          float64 // BLX-alpha extension of the parents' range
+	MutationRate   float64 // probability of each price being mutated, 0 uses 1 / number of goods
+	MutationEta    float64 // polynomial mutation distribution index
+	Elitism        int     // best individuals copied unchanged into the next generation, at most all but one
+}
+
+// DefaultOptions returns commonly used real-coded GA parameters
+func DefaultOptions() Options {
+	return Options{
+		Selection:      TournamentSelection,
+		TournamentSize: 2,
+		RankPressure:   1.5,
+		Crossover:      SBX,
+		CrossoverRate:  0.9,
+		Eta:            15,
+		Alpha:          0.5,
+		MutationRate:   0,
+		MutationEta:    20,
+		Elitism:        2,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"selection":     o.Selection.String(),
+		"crossover":     o.Crossover.String(),
+		"crossoverRate": strconv.FormatFloat(o.CrossoverRate, 'f', -1, 64),
+		"mutationRate":  strconv.FormatFloat(o.MutationRate, 'f', -1, 64),
+		"mutationEta":   strconv.FormatFloat(o.MutationEta, 'f', -1, 64),
+		"elitism":       strconv.Itoa(o.Elitism),
+	}
+	if o.Selection == TournamentSelection {
+		params["tournamentSize"] = strconv.Itoa(o.TournamentSize)
+	} else {
+		params["rankPressure"] = strconv.FormatFloat(o.RankPressure, 'f', -1, 64)
+	}
+	if o.Crossover == SBX {
+		params["eta"] = strconv.FormatFloat(o.Eta, 'f', -1, 64)
+	} else {
+		params["alpha"] = strconv.FormatFloat(o.Alpha, 'f', -1, 64)
+	}
+	return params
+}
@@ -0,0 +1,210 @@
+package localsearch
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Cooling determines how the temperature of an annealer falls
+type Cooling int
+
+const (
+	// GeometricCooling multiplies the temperature by Alpha after each plateau
+	GeometricCooling Cooling = iota
+	// AdaptiveCooling lowers the temperature according to the spread of revenues visited during the last plateau,
+	// cooling slowly while revenues vary widely (Aarts & van Laarhoven)
+	AdaptiveCooling
+)
+
+// Coolings lists every cooling schedule, for comparisons
+var Coolings = []Cooling{GeometricCooling, AdaptiveCooling}
+
+func (c Cooling) String() string {
+	switch c {
+	case GeometricCooling:
+		return "geometric"
+	case AdaptiveCooling:
+		return "adaptive"
+	}
+	return "unknown"
+}
+
+// AnnealOptions configures an Annealer
+type AnnealOptions struct {
+	Move        Move
+	Cooling     Cooling
+	Temperature float64 // initial temperature, 0 estimates one accepting Acceptance of worse neighbours
+	Acceptance  float64 // initial probability of accepting a worse neighbour, when estimating the temperature, within [0.01, 0.99]
+	Plateau     int     // moves made at each temperature
+	Alpha       float64 // geometric cooling factor
+	Delta       float64 // adaptive cooling distance, larger values cool faster
+}
+
+// DefaultAnnealOptions returns simulated annealing parameters suited to the pricing problem, cooling geometrically
+func DefaultAnnealOptions() AnnealOptions {
+	return AnnealOptions{
+		Move:        Perturb{Goods: 1, Sigma: 0.1},
+		Cooling:     GeometricCooling,
+		Temperature: 0,
+		Acceptance:  0.8,
+		Plateau:     100,
+		Alpha:       0.95,
+		Delta:       0.1,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o AnnealOptions) Params() map[string]string {
+	params := map[string]string{
+		"move":        moveName(o.Move),
+		"cooling":     o.Cooling.String(),
+		"temperature": strconv.FormatFloat(o.Temperature, 'f', -1, 64),
+		"plateau":     strconv.Itoa(o.Plateau),
+	}
+	if o.Temperature <= 0 {
+		params["acceptance"] = strconv.FormatFloat(o.Acceptance, 'f', -1, 64)
+	}
+	if o.Cooling == AdaptiveCooling {
+		params["delta"] = strconv.FormatFloat(o.Delta, 'f', -1, 64)
+	} else {
+		params["alpha"] = strconv.FormatFloat(o.Alpha, 'f', -1, 64)
+	}
+	return params
+}
+
+// Annealer is a simulated annealer, moving to better neighbours and to worse neighbours with a probability
+// that falls as the temperature cools
+type Annealer struct {
+	Prices          []float64 // current prices
+	Revenue         float64
+	BestPrices      []float64
+	BestRevenue     float64
+	Temperature     float64
+	Initial         float64   // initial temperature, given or estimated
+	AcceptanceRates []float64 // fraction of moves accepted during each completed plateau
+	opts            AnnealOptions
+	problem         pp.Problem
+
+	// current plateau
+	moves, accepted int
+	revenues        []float64
+}
+
+// NewAnnealer creates an annealer, moving and cooling according to opts
+// if pr is nil, the annealer is left empty until Init is called
+func NewAnnealer(opts AnnealOptions, pr pp.Problem) *Annealer {
+	sa := new(Annealer)
+	sa.opts = opts
+	if pr != nil {
+		sa.Init(pr)
+	}
+	return sa
+}
+
+// Init starts the annealer from random prices for problem pr at the initial temperature, discarding any previous progress
+func (sa *Annealer) Init(pr pp.Problem) {
+	sa.problem = pr
+	sa.Prices = randomPrices(pr)
+	sa.Revenue = evaluate(sa.Prices, pr)
+	sa.BestPrices, sa.BestRevenue = append([]float64{}, sa.Prices...), sa.Revenue
+	sa.AcceptanceRates = []float64{}
+	sa.moves, sa.accepted, sa.revenues = 0, 0, []float64{}
+	sa.Temperature = sa.opts.Temperature
+	if sa.Temperature <= 0 {
+		sa.Temperature = sa.initialTemperature()
+	}
+	sa.Initial = sa.Temperature
+}
+
+// Options returns the parameters of the annealer
+func (sa *Annealer) Options() AnnealOptions {
+	return sa.opts
+}
+
+// Params lists the parameters of the annealer, for recording alongside results
+func (sa *Annealer) Params() map[string]string {
+	return sa.opts.Params()
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (sa *Annealer) Best() ([]float64, float64) {
+	return append([]float64{}, sa.BestPrices...), sa.BestRevenue
+}
+
+// Step makes one move, accepting the neighbour by the Metropolis criterion
+// cools once Plateau moves have been made at the current temperature
+func (sa *Annealer) Step() {
+	neighbour := sa.opts.Move.Neighbour(sa.Prices, sa.problem.Bounds())
+	sa.moves++
+	if sa.problem.IsValid(neighbour) {
+		rev := evaluate(neighbour, sa.problem)
+		if rev >= sa.Revenue || rand.Float64() < math.Exp((rev-sa.Revenue)/sa.Temperature) {
+			sa.Prices, sa.Revenue = neighbour, rev
+			sa.accepted++
+			if rev > sa.BestRevenue {
+				sa.BestPrices, sa.BestRevenue = append([]float64{}, neighbour...), rev
+			}
+		}
+	}
+	sa.revenues = append(sa.revenues, sa.Revenue)
+
+	if sa.moves >= sa.opts.Plateau {
+		sa.cool()
+	}
+}
+
+// cool lowers the temperature according to the cooling schedule, and begins a new plateau
+func (sa *Annealer) cool() {
+	sa.AcceptanceRates = append(sa.AcceptanceRates, float64(sa.accepted)/float64(sa.moves))
+	switch sa.opts.Cooling {
+	case AdaptiveCooling:
+		if sd := stdDev(sa.revenues); sd > 0 {
+			sa.Temperature /= 1 + sa.Temperature*math.Log(1+sa.opts.Delta)/(3*sd)
+			break
+		}
+		sa.Temperature *= sa.opts.Alpha // no spread to adapt to, so fall back to geometric cooling
+	default:
+		sa.Temperature *= sa.opts.Alpha
+	}
+	sa.moves, sa.accepted, sa.revenues = 0, 0, sa.revenues[:0]
+}
+
+// initialTemperature samples a plateau of neighbours of the current prices, returning the temperature
+// at which the average loss of revenue to a worse neighbour is accepted with probability Acceptance,
+// clamped to [0.01, 0.99]
+func (sa *Annealer) initialTemperature() float64 {
+	var loss float64
+	var worse int
+	for k := 0; k < sa.opts.Plateau; k++ {
+		neighbour := sa.opts.Move.Neighbour(sa.Prices, sa.problem.Bounds())
+		if !sa.problem.IsValid(neighbour) {
+			continue
+		}
+		if rev := evaluate(neighbour, sa.problem); rev < sa.Revenue {
+			loss += sa.Revenue - rev
+			worse++
+		}
+	}
+	if worse == 0 {
+		return 1
+	}
+	// a probability of 0 would need no temperature, and 1 an infinite one
+	acceptance := math.Min(math.Max(sa.opts.Acceptance, 0.01), 0.99)
+	return -(loss / float64(worse)) / math.Log(acceptance)
+}
+
+// stdDev returns the population standard deviation of values
+func stdDev(values []float64) float64 {
+	var mean, sq float64
+	for _, v := range values {
+		mean += v
+	}
+	mean /= float64(len(values))
+	for _, v := range values {
+		sq += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sq / float64(len(values)))
+}
@@ -0,0 +1,121 @@
+package localsearch
+
+import (
+	"math"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Improvement determines which neighbour a hill climber moves to
+type Improvement int
+
+const (
+	// FirstImprovement moves to the first sampled neighbour with a higher revenue
+	FirstImprovement Improvement = iota
+	// BestImprovement samples every neighbour, and moves to the best if it has a higher revenue
+	BestImprovement
+)
+
+// Improvements lists every improvement strategy, for comparisons
+var Improvements = []Improvement{FirstImprovement, BestImprovement}
+
+func (i Improvement) String() string {
+	switch i {
+	case FirstImprovement:
+		return "first"
+	case BestImprovement:
+		return "best"
+	}
+	return "unknown"
+}
+
+// ClimbOptions configures a HillClimber
+type ClimbOptions struct {
+	Move        Move
+	Improvement Improvement
+	Neighbours  int // neighbours sampled each step
+}
+
+// DefaultClimbOptions returns hill climbing parameters suited to the pricing problem
+func DefaultClimbOptions() ClimbOptions {
+	return ClimbOptions{
+		Move:        Perturb{Goods: 1, Sigma: 0.1},
+		Improvement: FirstImprovement,
+		Neighbours:  20,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o ClimbOptions) Params() map[string]string {
+	return map[string]string{
+		"move":        moveName(o.Move),
+		"improvement": o.Improvement.String(),
+		"neighbours":  strconv.Itoa(o.Neighbours),
+	}
+}
+
+// HillClimber is a stochastic hill climber, moving from its current prices only to neighbours with a higher revenue
+type HillClimber struct {
+	Prices       []float64
+	Revenue      float64
+	Improvements int // steps that moved to a better neighbour
+	opts         ClimbOptions
+	problem      pp.Problem
+}
+
+// NewHillClimber creates a hill climber, moving according to opts
+// if pr is nil, the climber is left empty until Init is called
+func NewHillClimber(opts ClimbOptions, pr pp.Problem) *HillClimber {
+	hc := new(HillClimber)
+	hc.opts = opts
+	if pr != nil {
+		hc.Init(pr)
+	}
+	return hc
+}
+
+// Init starts the climber from random prices for problem pr, discarding any previous progress
+func (hc *HillClimber) Init(pr pp.Problem) {
+	hc.problem = pr
+	hc.Prices = randomPrices(pr)
+	hc.Revenue = evaluate(hc.Prices, pr)
+	hc.Improvements = 0
+}
+
+// Params lists the parameters of the climber, for recording alongside results
+func (hc *HillClimber) Params() map[string]string {
+	return hc.opts.Params()
+}
+
+// Options returns the parameters of the climber
+func (hc *HillClimber) Options() ClimbOptions {
+	return hc.opts
+}
+
+// Best returns a copy of the current prices, and their revenue
+func (hc *HillClimber) Best() ([]float64, float64) {
+	return append([]float64{}, hc.Prices...), hc.Revenue
+}
+
+// Step samples up to Neighbours neighbours of the current prices, moving to a better one according to Improvement
+func (hc *HillClimber) Step() {
+	bestPrices, bestRevenue := []float64(nil), math.Inf(-1)
+	for k := 0; k < hc.opts.Neighbours; k++ {
+		neighbour := hc.opts.Move.Neighbour(hc.Prices, hc.problem.Bounds())
+		if !hc.problem.IsValid(neighbour) {
+			continue
+		}
+		rev := evaluate(neighbour, hc.problem)
+		if rev > bestRevenue {
+			bestPrices, bestRevenue = neighbour, rev
+		}
+		if hc.opts.Improvement == FirstImprovement && rev > hc.Revenue {
+			break
+		}
+	}
+	if bestRevenue > hc.Revenue {
+		hc.Prices, hc.Revenue = bestPrices, bestRevenue
+		hc.Improvements++
+	}
+}
@@ -0,0 +1,284 @@
+package localsearch
+
+import (
+	"math"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// LineSearch determines how each good's price is maximised, with the other prices fixed
+type LineSearch int
+
+const (
+	// GoldenSection narrows the bracket by the golden ratio each evaluation
+	GoldenSection LineSearch = iota
+	// Brent fits parabolas through the best three prices, falling back to golden section steps (Brent's method)
+	Brent
+)
+
+// LineSearches lists every line search, for comparisons
+var LineSearches = []LineSearch{GoldenSection, Brent}
+
+func (l LineSearch) String() string {
+	switch l {
+	case GoldenSection:
+		return "goldenSection"
+	case Brent:
+		return "brent"
+	}
+	return "unknown"
+}
+
+// CoordinateOptions configures a CoordinateAscent
+type CoordinateOptions struct {
+	LineSearch LineSearch
+	Tolerance  float64 // width of the final bracket, as a fraction of the good's bound range
+	MaxLine    int     // evaluations of each line search
+	// Restart starts again from random prices after reaching a coordinate-wise optimum, keeping the best,
+	// otherwise the search stops stepping once converged
+	Restart bool
+}
+
+// DefaultCoordinateOptions returns coordinate ascent parameters suited to the pricing problem, using Brent's method
+func DefaultCoordinateOptions() CoordinateOptions {
+	return CoordinateOptions{
+		LineSearch: Brent,
+		Tolerance:  1e-6,
+		MaxLine:    100,
+		Restart:    true,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o CoordinateOptions) Params() map[string]string {
+	return map[string]string{
+		"lineSearch": o.LineSearch.String(),
+		"tolerance":  strconv.FormatFloat(o.Tolerance, 'f', -1, 64),
+		"maxLine":    strconv.Itoa(o.MaxLine),
+		"restart":    strconv.FormatBool(o.Restart),
+	}
+}
+
+// CoordinateAscent maximises one good's price at a time, holding the others fixed, cycling through the goods
+// a full cycle which moves no price by more than the tolerance has reached a coordinate-wise optimum,
+// where no single price can be changed to increase the revenue
+type CoordinateAscent struct {
+	Prices       []float64 // current prices
+	Revenue      float64
+	BestPrices   []float64
+	BestRevenue  float64
+	Cycles       int       // full cycles through the goods since Init
+	Optima       []float64 // revenue of each coordinate-wise optimum reached, in the order reached
+	FirstOptimum int       // cycles made when the first coordinate-wise optimum was reached
+	Converged    bool      // the current prices are a coordinate-wise optimum
+	opts         CoordinateOptions
+	problem      pp.Problem
+	good         int  // next good to maximise
+	moved        bool // a price has moved by more than the tolerance during the current cycle
+}
+
+// NewCoordinateAscent creates a coordinate ascent, maximising each good according to opts
+// if pr is nil, the search is left empty until Init is called
+func NewCoordinateAscent(opts CoordinateOptions, pr pp.Problem) *CoordinateAscent {
+	ca := new(CoordinateAscent)
+	ca.opts = opts
+	if pr != nil {
+		ca.Init(pr)
+	}
+	return ca
+}
+
+// Init starts the search from random prices for problem pr, discarding any previous progress
+func (ca *CoordinateAscent) Init(pr pp.Problem) {
+	ca.problem = pr
+	ca.BestPrices, ca.BestRevenue = nil, math.Inf(-1)
+	ca.Cycles, ca.FirstOptimum = 0, 0
+	ca.Optima = []float64{}
+	ca.start()
+}
+
+// start begins a new ascent from random prices
+func (ca *CoordinateAscent) start() {
+	ca.Prices = randomPrices(ca.problem)
+	ca.Revenue = evaluate(ca.Prices, ca.problem)
+	ca.Converged = false
+	ca.good, ca.moved = 0, false
+	ca.keepBest()
+}
+
+// Options returns the parameters of the search
+func (ca *CoordinateAscent) Options() CoordinateOptions {
+	return ca.opts
+}
+
+// Params lists the parameters of the search, for recording alongside results
+func (ca *CoordinateAscent) Params() map[string]string {
+	return ca.opts.Params()
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (ca *CoordinateAscent) Best() ([]float64, float64) {
+	return append([]float64{}, ca.BestPrices...), ca.BestRevenue
+}
+
+// Step maximises the revenue over the price of the next good, with the other prices fixed
+// at the end of a cycle, checks whether a coordinate-wise optimum has been reached
+func (ca *CoordinateAscent) Step() {
+	if ca.Converged {
+		if !ca.opts.Restart {
+			return
+		}
+		ca.start()
+	}
+
+	i := ca.good
+	bounds := ca.problem.Bounds()
+	low, high := bounds[i][0], bounds[i][1]
+	trial := append([]float64{}, ca.Prices...)
+	f := func(price float64) float64 {
+		trial[i] = price
+		if !ca.problem.IsValid(trial) {
+			return math.Inf(-1)
+		}
+		return evaluate(trial, ca.problem)
+	}
+	tol := ca.opts.Tolerance * (high - low)
+	var price, rev float64
+	if ca.opts.LineSearch == GoldenSection {
+		price, rev = goldenSection(f, low, high, tol, ca.opts.MaxLine)
+	} else {
+		price, rev = brent(f, low, high, tol, ca.opts.MaxLine)
+	}
+	// the revenue need not be unimodal in the price, so only move if the line search found better
+	if rev > ca.Revenue {
+		if math.Abs(price-ca.Prices[i]) > tol {
+			ca.moved = true
+		}
+		ca.Prices[i], ca.Revenue = price, rev
+		ca.keepBest()
+	}
+
+	ca.good++
+	if ca.good == len(ca.Prices) {
+		ca.Cycles++
+		if !ca.moved {
+			ca.Converged = true
+			if len(ca.Optima) == 0 {
+				ca.FirstOptimum = ca.Cycles
+			}
+			ca.Optima = append(ca.Optima, ca.Revenue)
+		}
+		ca.good, ca.moved = 0, false
+	}
+}
+
+// keepBest records the current prices if they are the best found
+func (ca *CoordinateAscent) keepBest() {
+	if ca.Revenue > ca.BestRevenue {
+		ca.BestPrices, ca.BestRevenue = append([]float64{}, ca.Prices...), ca.Revenue
+	}
+}
+
+// invPhi is the inverse of the golden ratio, and invPhi2 its square
+var (
+	invPhi  = (math.Sqrt(5) - 1) / 2
+	invPhi2 = (3 - math.Sqrt(5)) / 2
+)
+
+// goldenSection maximises f over [a, b] by golden section search, until the bracket is narrower than tol
+// or maxEvals evaluations have been made, returning the best point evaluated and its value
+func goldenSection(f func(float64) float64, a, b, tol float64, maxEvals int) (float64, float64) {
+	c, d := b-invPhi*(b-a), a+invPhi*(b-a)
+	fc, fd := f(c), f(d)
+	for evals := 2; b-a > tol && evals < maxEvals; evals++ {
+		if fc > fd {
+			b, d, fd = d, c, fc
+			c = b - invPhi*(b-a)
+			fc = f(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + invPhi*(b-a)
+			fd = f(d)
+		}
+	}
+	if fc > fd {
+		return c, fc
+	}
+	return d, fd
+}
+
+// brent maximises f over [a, b] by Brent's method, until the bracket is narrower than tol
+// or maxEvals evaluations have been made, returning the best point evaluated and its value
+// (after Brent, Algorithms for Minimization without Derivatives, minimising -f)
+func brent(f func(float64) float64, a, b, tol float64, maxEvals int) (float64, float64) {
+	g := func(x float64) float64 { return -f(x) }
+	x := a + invPhi2*(b-a)
+	w, v := x, x
+	fx := g(x)
+	fw, fv := fx, fx
+	var d, e float64
+	for evals := 1; evals < maxEvals; evals++ {
+		m := (a + b) / 2
+		tol1 := tol/3 + 1e-10*math.Abs(x)
+		tol2 := 2 * tol1
+		if math.Abs(x-m) <= tol2-(b-a)/2 {
+			break
+		}
+
+		golden := true
+		if math.Abs(e) > tol1 && !math.IsInf(fx, 0) && !math.IsInf(fw, 0) && !math.IsInf(fv, 0) {
+			// parabola through x, w and v
+			r := (x - w) * (fx - fv)
+			q := (x - v) * (fx - fw)
+			p := (x-v)*q - (x-w)*r
+			q = 2 * (q - r)
+			if q > 0 {
+				p = -p
+			} else {
+				q = -q
+			}
+			if math.Abs(p) < math.Abs(q*e/2) && p > q*(a-x) && p < q*(b-x) {
+				e, d = d, p/q
+				golden = false
+				if u := x + d; u-a < tol2 || b-u < tol2 {
+					d = math.Copysign(tol1, m-x)
+				}
+			}
+		}
+		if golden {
+			if x < m {
+				e = b - x
+			} else {
+				e = a - x
+			}
+			d = invPhi2 * e
+		}
+
+		u := x + d
+		if math.Abs(d) < tol1 {
+			u = x + math.Copysign(tol1, d)
+		}
+		fu := g(u)
+		if fu <= fx {
+			if u < x {
+				b = x
+			} else {
+				a = x
+			}
+			v, fv, w, fw, x, fx = w, fw, x, fx, u, fu
+		} else {
+			if u < x {
+				a = u
+			} else {
+				b = u
+			}
+			if fu <= fw || w == x {
+				v, fv, w, fw = w, fw, u, fu
+			} else if fu <= fv || v == x || v == w {
+				v, fv = u, fu
+			}
+		}
+	}
+	return x, -fx
+}
@@ -0,0 +1,361 @@
+package localsearch
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_movesWithinBounds(t *testing.T) {
+	bounds := [][]float64{{1, 2}, {0, 10}, {5, 6}, {3, 4}}
+	prices := []float64{1, 10, 5.5, 3.5}
+	for _, m := range []Move{Perturb{Goods: 2, Sigma: 1}, Resample{Goods: 2}} {
+		for k := 0; k < 100; k++ {
+			neighbour := m.Neighbour(prices, bounds)
+			changed := 0
+			for i := range neighbour {
+				if neighbour[i] < bounds[i][0] || neighbour[i] > bounds[i][1] {
+					t.Fatalf("%v moved price %v to %v, outside %v", m, i, neighbour[i], bounds[i])
+				}
+				if neighbour[i] != prices[i] {
+					changed++
+				}
+			}
+			if changed > 2 {
+				t.Fatalf("%v changed %v prices, expected at most 2", m, changed)
+			}
+		}
+	}
+	if prices[0] != 1 || prices[1] != 10 {
+		t.Errorf("moves changed the original prices to %v", prices)
+	}
+}
+
+func Test_initialTemperature(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	temperatures := map[float64]float64{}
+	for _, acceptance := range []float64{-1, 0, 0.01, 0.5, 0.99, 1, 2} {
+		opts := DefaultAnnealOptions()
+		opts.Acceptance = acceptance
+		rand.Seed(1)
+		sa := NewAnnealer(opts, pr)
+		if sa.Temperature <= 0 || math.IsInf(sa.Temperature, 0) || math.IsNaN(sa.Temperature) {
+			t.Errorf("acceptance %v gave temperature %v", acceptance, sa.Temperature)
+		}
+		temperatures[acceptance] = sa.Temperature
+	}
+	// acceptances outside the range are clamped to it, and higher acceptance needs a higher temperature
+	if temperatures[-1] != temperatures[0.01] || temperatures[0] != temperatures[0.01] ||
+		temperatures[1] != temperatures[0.99] || temperatures[2] != temperatures[0.99] {
+		t.Errorf("acceptance not clamped to [0.01, 0.99] : %v", temperatures)
+	}
+	if !(temperatures[0.01] < temperatures[0.5] && temperatures[0.5] < temperatures[0.99]) {
+		t.Errorf("temperature should rise with acceptance : %v", temperatures)
+	}
+}
+
+func Test_cooling(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(5, 0, false)
+	opts := DefaultAnnealOptions()
+	opts.Temperature, opts.Plateau = 100, 10
+
+	// geometric cooling multiplies the temperature by Alpha after each plateau
+	sa := NewAnnealer(opts, pr)
+	for i := 0; i < 30; i++ {
+		if i > 0 && i%10 == 0 && math.Abs(sa.Temperature-100*math.Pow(0.95, float64(i/10))) > 1e-9 {
+			t.Errorf("step %v : temperature %v, expected %v", i, sa.Temperature, 100*math.Pow(0.95, float64(i/10)))
+		}
+		sa.Step()
+	}
+	if len(sa.AcceptanceRates) != 3 {
+		t.Errorf("expected an acceptance rate for each of 3 plateaus, actual %v", sa.AcceptanceRates)
+	}
+
+	// adaptive cooling divides by 1 + T ln(1 + Delta) / 3 sigma, for the spread sigma of the plateau's revenues
+	opts.Cooling = AdaptiveCooling
+	sa = NewAnnealer(opts, pr)
+	sa.revenues, sa.moves = []float64{10, 20, 30, 40}, 10
+	sd := math.Sqrt(125)
+	sa.cool()
+	if expected := 100 / (1 + 100*math.Log(1.1)/(3*sd)); math.Abs(sa.Temperature-expected) > 1e-9 {
+		t.Errorf("adaptive temperature %v, expected %v", sa.Temperature, expected)
+	}
+	// a wider spread cools more slowly
+	wide := NewAnnealer(opts, pr)
+	wide.revenues, wide.moves = []float64{0, 100, 200, 300}, 10
+	wide.cool()
+	if wide.Temperature <= sa.Temperature {
+		t.Errorf("wider spread cooled to %v, below %v", wide.Temperature, sa.Temperature)
+	}
+	// no spread falls back to geometric cooling
+	sa.Temperature, sa.revenues, sa.moves = 100, []float64{5, 5, 5}, 10
+	sa.cool()
+	if sa.Temperature != 95 {
+		t.Errorf("expected geometric cooling without spread, actual %v", sa.Temperature)
+	}
+}
+
+func Test_metropolis(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+
+	// a very cold annealer never accepts a worse neighbour
+	opts := DefaultAnnealOptions()
+	opts.Temperature, opts.Alpha = 1e-9, 1
+	cold := NewAnnealer(opts, pr)
+	for i := 0; i < 2000; i++ {
+		before := cold.Revenue
+		cold.Step()
+		if cold.Revenue < before {
+			t.Fatalf("cold annealer accepted a loss from %v to %v", before, cold.Revenue)
+		}
+	}
+
+	// a very hot one accepts almost every move, wandering away from its best
+	opts.Temperature = 1e9
+	hot := NewAnnealer(opts, pr)
+	for i := 0; i < 2000; i++ {
+		hot.Step()
+	}
+	for _, rate := range hot.AcceptanceRates {
+		if rate < 0.95 {
+			t.Errorf("hot annealer accepted only %v of moves", rate)
+		}
+	}
+	if hot.Revenue >= hot.BestRevenue {
+		t.Errorf("hot annealer should have accepted worse prices than its best %v", hot.BestRevenue)
+	}
+}
+
+// counting counts the revenues evaluated on the wrapped problem
+type counting struct {
+	pp.Problem
+	evaluations int
+}
+
+func (c *counting) Evaluate(prices []float64) (float64, error) {
+	c.evaluations++
+	return c.Problem.Evaluate(prices)
+}
+
+func Test_hillClimbing(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+	evaluations := map[Improvement]int{}
+	for _, imp := range Improvements {
+		opts := DefaultClimbOptions()
+		opts.Improvement = imp
+		c := &counting{Problem: pr}
+		hc := NewHillClimber(opts, c)
+		initial := hc.Revenue
+		for i := 0; i < 200; i++ {
+			before := hc.Revenue
+			hc.Step()
+			if hc.Revenue < before {
+				t.Fatalf("%v : climber moved from %v to %v", imp, before, hc.Revenue)
+			}
+		}
+		if hc.Revenue <= initial || hc.Improvements == 0 {
+			t.Errorf("%v : revenue %v did not improve on %v", imp, hc.Revenue, initial)
+		}
+		evaluations[imp] = c.evaluations
+	}
+	// best improvement evaluates every neighbour, first improvement stops at the first better one
+	if evaluations[BestImprovement] != 1+200*DefaultClimbOptions().Neighbours || evaluations[FirstImprovement] >= evaluations[BestImprovement] {
+		t.Errorf("unexpected evaluations : %v", evaluations)
+	}
+}
+
+// peak earns 100 less the squared distance of prices from centre, within bounds [0, 10]
+// prices of good 0 above ceiling are invalid
+type peak struct {
+	centre  []float64
+	ceiling float64
+}
+
+func (p peak) Evaluate(prices []float64) (float64, error) {
+	rev := 100.0
+	for i, x := range prices {
+		rev -= (x - p.centre[i]) * (x - p.centre[i])
+	}
+	return rev, nil
+}
+
+func (p peak) Bounds() [][]float64 {
+	bounds := make([][]float64, len(p.centre))
+	for i := range bounds {
+		bounds[i] = []float64{0, 10}
+	}
+	return bounds
+}
+
+func (p peak) IsValid(prices []float64) bool {
+	for _, x := range prices {
+		if x < 0 || x > 10 {
+			return false
+		}
+	}
+	return prices[0] <= p.ceiling
+}
+
+var polishers = []Polisher{NelderMead{Scale: 0.05}, PatternSearch{Step: 0.05}, CoordinateSearch{Step: 0.05}}
+
+func Test_polishersImprove(t *testing.T) {
+	pr := peak{centre: []float64{2, 7.5, 4, 9.25}, ceiling: 10}
+	for _, p := range polishers {
+		for _, budget := range []int{1, 10, 100, 2000} {
+			c := &counting{Problem: pr}
+			start := []float64{5, 5, 5, 5}
+			prices, rev := p.Polish(start, c, budget)
+			if c.evaluations > budget {
+				t.Errorf("%v spent %v evaluations of a budget of %v", p, c.evaluations, budget)
+			}
+			if check, _ := pr.Evaluate(prices); check != rev || rev < 100-4*9 {
+				t.Errorf("%v reported %v for prices with revenue %v, starting from %v", p, rev, check, 100-4*9)
+			}
+			if budget == 2000 && rev < 100-1e-6 {
+				t.Errorf("%v polished to %v at %v, expected the peak at %v", p, rev, prices, pr.centre)
+			}
+			if start[0] != 5 {
+				t.Errorf("%v changed the starting prices to %v", p, start)
+			}
+		}
+	}
+}
+
+func Test_polishersInvalidStart(t *testing.T) {
+	for _, p := range polishers {
+		// prices outside the bounds start from their clamped prices
+		pr := peak{centre: []float64{2, 7.5}, ceiling: 10}
+		prices, rev := p.Polish([]float64{-5, 20}, pr, 1)
+		if fmt.Sprint(prices) != "[0 10]" || rev != 100-4-6.25 {
+			t.Errorf("%v started from %v with revenue %v, expected the clamped prices", p, prices, rev)
+		}
+		if _, rev = p.Polish([]float64{-5, 20}, pr, 2000); rev < 100-1e-6 {
+			t.Errorf("%v polished clamped prices to %v", p, rev)
+		}
+
+		// prices invalid once clamped have no revenue, and are returned when no valid prices are found
+		pr.ceiling = 8
+		c := &counting{Problem: pr}
+		prices, rev = p.Polish([]float64{9, 20}, c, 100)
+		if !math.IsInf(rev, -1) || fmt.Sprint(prices) != "[9 10]" || c.evaluations != 0 {
+			t.Errorf("%v returned %v with revenue %v after %v evaluations", p, prices, rev, c.evaluations)
+		}
+	}
+}
+
+func Test_lineSearches(t *testing.T) {
+	tests := []struct {
+		name     string
+		f        func(float64) float64
+		a, b, at float64
+	}{
+		{"quadratic", func(x float64) float64 { return -(x - 2) * (x - 2) }, 0, 5, 2},
+		{"upper bound", func(x float64) float64 { return x }, 0, 1, 1},
+		{"revenue", func(x float64) float64 { return x * math.Exp(-x/3) }, 0.5, 10, 3},
+	}
+	for _, tt := range tests {
+		for _, search := range []func(func(float64) float64, float64, float64, float64, int) (float64, float64){goldenSection, brent} {
+			x, fx := search(tt.f, tt.a, tt.b, 1e-8, 200)
+			if math.Abs(x-tt.at) > 1e-6 || fx != tt.f(x) {
+				t.Errorf("%v : found %v (%v), expected %v", tt.name, x, fx, tt.at)
+			}
+		}
+	}
+}
+
+func Test_lineSearchBracketing(t *testing.T) {
+	for _, search := range []func(func(float64) float64, float64, float64, float64, int) (float64, float64){goldenSection, brent} {
+		for _, maxEvals := range []int{3, 10, 200} {
+			var evaluated []float64
+			f := func(x float64) float64 {
+				evaluated = append(evaluated, x)
+				return -(x - 7) * (x - 7)
+			}
+			x, fx := search(f, 2, 10, 1e-8, maxEvals)
+			if len(evaluated) > maxEvals {
+				t.Errorf("%v evaluations, expected at most %v", len(evaluated), maxEvals)
+			}
+			found := false
+			for _, e := range evaluated {
+				if e < 2 || e > 10 {
+					t.Errorf("evaluated %v, outside the bracket [2, 10]", e)
+				}
+				found = found || e == x
+			}
+			// the best point evaluated is returned, however few evaluations are allowed
+			if !found || fx != -(x-7)*(x-7) {
+				t.Errorf("returned %v (%v), not an evaluated point", x, fx)
+			}
+			for _, e := range evaluated {
+				if -(e-7)*(e-7) > fx {
+					t.Errorf("returned %v, but %v was better", x, e)
+				}
+			}
+		}
+
+		// a wide tolerance stops once the bracket is narrower than it
+		evals := 0
+		search(func(x float64) float64 { evals++; return -(x - 7) * (x - 7) }, 2, 10, 1, 200)
+		if evals > 10 {
+			t.Errorf("%v evaluations to narrow the bracket to 1", evals)
+		}
+	}
+}
+
+func Test_coordinateAscent(t *testing.T) {
+	pr := peak{centre: []float64{2, 7.5, 4, 9.25}, ceiling: 10}
+	for _, search := range LineSearches {
+		opts := DefaultCoordinateOptions()
+		opts.LineSearch = search
+		opts.Restart = false
+		ca := NewCoordinateAscent(opts, pr)
+		for i := 0; i < 100 && !ca.Converged; i++ {
+			ca.Step()
+		}
+		// a separable revenue is maximised in one cycle, and the next confirms nothing moves
+		if !ca.Converged || ca.Cycles > 3 || ca.FirstOptimum != ca.Cycles || len(ca.Optima) != 1 || ca.Optima[0] != ca.BestRevenue {
+			t.Errorf("%v : converged %v after %v cycles, first optimum %v, optima %v", search, ca.Converged, ca.Cycles, ca.FirstOptimum, ca.Optima)
+		}
+		if _, rev := ca.Best(); rev < 100-1e-9 {
+			t.Errorf("%v found %v at %v, expected the peak", search, rev, ca.BestPrices)
+		}
+		// without restarts, a converged search stops stepping
+		cycles := ca.Cycles
+		for i := 0; i < 20; i++ {
+			ca.Step()
+		}
+		if ca.Cycles != cycles || len(ca.Optima) != 1 {
+			t.Errorf("%v : stepped on after converging", search)
+		}
+
+		// with restarts, it starts again from random prices, recording each optimum and keeping the best
+		opts.Restart = true
+		ca = NewCoordinateAscent(opts, pr)
+		for len(ca.Optima) < 3 && ca.Cycles < 100 {
+			ca.Step()
+		}
+		if len(ca.Optima) != 3 || ca.FirstOptimum >= ca.Cycles || ca.BestRevenue < 100-1e-9 {
+			t.Errorf("%v : optima %v after %v cycles, first at %v", search, ca.Optima, ca.Cycles, ca.FirstOptimum)
+		}
+
+		// invalid prices are never moved to, so a peak beyond the ceiling is approached from below it
+		capped := peak{centre: []float64{9, 5}, ceiling: 8}
+		ca = NewCoordinateAscent(opts, capped)
+		for !ca.Converged {
+			ca.Step()
+			if !capped.IsValid(ca.Prices) {
+				t.Fatalf("%v : moved to invalid prices %v", search, ca.Prices)
+			}
+		}
+		if math.Abs(ca.BestPrices[0]-8) > 1e-4 || math.Abs(ca.BestPrices[1]-5) > 1e-4 {
+			t.Errorf("%v : converged to %v, expected [8 5]", search, ca.BestPrices)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package localsearch
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Move creates a neighbour of a set of prices
+// Neighbour must return a new slice of prices within bounds, leaving prices unchanged
+type Move interface {
+	Neighbour(prices []float64, bounds [][]float64) []float64
+}
+
+// Perturb adds normally distributed noise to Goods random prices
+type Perturb struct {
+	Goods int     // prices changed by each move, at least 1
+	Sigma float64 // standard deviation, as a fraction of each good's bound range
+}
+
+// Resample redraws Goods random prices uniformly within their bounds
+type Resample struct {
+	Goods int // prices changed by each move, at least 1
+}
+
+func (p Perturb) String() string {
+	return "perturb(" + strconv.Itoa(p.Goods) + ", " + strconv.FormatFloat(p.Sigma, 'f', -1, 64) + ")"
+}
+
+func (r Resample) String() string {
+	return "resample(" + strconv.Itoa(r.Goods) + ")"
+}
+
+// Neighbour perturbs Goods random prices, clamping to the bounds
+func (p Perturb) Neighbour(prices []float64, bounds [][]float64) []float64 {
+	neighbour := append([]float64{}, prices...)
+	for _, i := range chooseGoods(len(prices), p.Goods) {
+		neighbour[i] += rand.NormFloat64() * p.Sigma * (bounds[i][1] - bounds[i][0])
+		neighbour[i] = math.Min(math.Max(neighbour[i], bounds[i][0]), bounds[i][1])
+	}
+	return neighbour
+}
+
+// Neighbour redraws Goods random prices
+func (r Resample) Neighbour(prices []float64, bounds [][]float64) []float64 {
+	neighbour := append([]float64{}, prices...)
+	for _, i := range chooseGoods(len(prices), r.Goods) {
+		neighbour[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+	}
+	return neighbour
+}
+
+// chooseGoods returns k different random indices of n goods, at least 1 and at most n
+func chooseGoods(n, k int) []int {
+	if k < 1 {
+		k = 1
+	}
+	if k > n {
+		k = n
+	}
+	return rand.Perm(n)[:k]
+}
+
+// moveName names a move for recording alongside results
+func moveName(m Move) string {
+	if s, ok := m.(fmt.Stringer); ok {
+		return s.String()
+	}
+	return fmt.Sprintf("%T", m)
+}
+
+// randomPrices generates random prices within the bounds of the problem
+func randomPrices(pr pp.Problem) []float64 {
+	bounds := pr.Bounds()
+	prices := make([]float64, len(bounds))
+	for !pr.IsValid(prices) { // while not valid, select prices at random
+		for i := range prices {
+			prices[i] = bounds[i][0] + rand.Float64()*(bounds[i][1]-bounds[i][0])
+		}
+	}
+	return prices
+}
+
+// evaluate returns the revenue of prices, exiting if the problem cannot evaluate them
+func evaluate(prices []float64, pr pp.Problem) float64 {
+	rev, err := pr.Evaluate(prices)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rev
+}
@@ -0,0 +1,262 @@
+package localsearch
+
+import (
+	"math"
+	"sort"
+	"strconv"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// Polisher is a local optimiser, improving a set of prices within a budget of evaluations
+// Polish returns the best prices found and their revenue, which are never worse than prices clamped to the bounds
+// prices that are invalid even once clamped start with a revenue of negative infinity
+type Polisher interface {
+	Polish(prices []float64, pr pp.Problem, budget int) ([]float64, float64)
+}
+
+// NelderMead polishes with the Nelder-Mead simplex method
+type NelderMead struct {
+	Scale float64 // size of the starting simplex, as a fraction of each good's bound range
+}
+
+// PatternSearch polishes with Hooke-Jeeves pattern search, exploring each good then moving along the improving direction
+type PatternSearch struct {
+	Step float64 // starting step, as a fraction of each good's bound range
+}
+
+// CoordinateSearch polishes one good at a time, trying a step either side of its price
+// and halving the step once a full cycle of goods fails to improve
+type CoordinateSearch struct {
+	Step float64 // starting step, as a fraction of each good's bound range
+}
+
+func (nm NelderMead) String() string {
+	return "nelderMead(" + strconv.FormatFloat(nm.Scale, 'f', -1, 64) + ")"
+}
+
+func (ps PatternSearch) String() string {
+	return "patternSearch(" + strconv.FormatFloat(ps.Step, 'f', -1, 64) + ")"
+}
+
+func (cs CoordinateSearch) String() string {
+	return "coordinateSearch(" + strconv.FormatFloat(cs.Step, 'f', -1, 64) + ")"
+}
+
+// minStep is the step, as a fraction of the bound range, below which a polisher stops early
+const minStep = 1e-9
+
+// budgeted evaluates prices for a polisher, clamping them to the bounds and counting evaluations against a budget
+// the best prices evaluated are kept, so a polisher can stop at any point
+type budgeted struct {
+	problem     pp.Problem
+	budget      int
+	bestPrices  []float64
+	bestRevenue float64
+}
+
+// newBudgeted starts from a copy of prices clamped to the bounds, spending one evaluation of the budget on them
+func newBudgeted(prices []float64, pr pp.Problem, budget int) *budgeted {
+	b := &budgeted{problem: pr, budget: budget, bestPrices: append([]float64{}, prices...), bestRevenue: math.Inf(-1)}
+	b.eval(b.bestPrices)
+	return b
+}
+
+// exhausted is true once the budget has been used
+func (b *budgeted) exhausted() bool {
+	return b.budget <= 0
+}
+
+// eval clamps prices in place and returns their revenue, negative infinity if they are invalid
+func (b *budgeted) eval(prices []float64) float64 {
+	bounds := b.problem.Bounds()
+	for i := range prices {
+		prices[i] = math.Min(math.Max(prices[i], bounds[i][0]), bounds[i][1])
+	}
+	if !b.problem.IsValid(prices) {
+		return math.Inf(-1)
+	}
+	b.budget--
+	rev := evaluate(prices, b.problem)
+	if rev > b.bestRevenue {
+		b.bestPrices, b.bestRevenue = append([]float64{}, prices...), rev
+	}
+	return rev
+}
+
+// Polish runs the simplex method from a simplex around prices until the budget is used
+// the simplex is rebuilt around the best prices whenever it collapses, which it may do against a bound,
+// until a rebuilt simplex fails to improve
+func (nm NelderMead) Polish(prices []float64, pr pp.Problem, budget int) ([]float64, float64) {
+	b := newBudgeted(prices, pr, budget)
+	for !b.exhausted() {
+		start := b.bestRevenue
+		nm.simplex(b)
+		if b.bestRevenue <= start {
+			break
+		}
+	}
+	return b.bestPrices, b.bestRevenue
+}
+
+// simplex runs the simplex method from a simplex around the best prices of b, until it collapses or the budget is used
+func (nm NelderMead) simplex(b *budgeted) {
+	bounds := b.problem.Bounds()
+	n := len(b.bestPrices)
+
+	simplex := []vertex{{append([]float64{}, b.bestPrices...), b.bestRevenue}}
+	for i := 0; i < n && !b.exhausted(); i++ {
+		x := append([]float64{}, simplex[0].x...)
+		step := nm.Scale * (bounds[i][1] - bounds[i][0])
+		if x[i]+step > bounds[i][1] {
+			step = -step // step into the bounds
+		}
+		x[i] += step
+		simplex = append(simplex, vertex{x, b.eval(x)})
+	}
+	if len(simplex) < n+1 {
+		return
+	}
+
+	// standard coefficients of reflection (1), expansion (2), contraction (0.5) and shrinking (0.5)
+	along := func(centroid, x []float64, t float64) []float64 {
+		p := make([]float64, n)
+		for i := range p {
+			p[i] = centroid[i] + t*(x[i]-centroid[i])
+		}
+		return p
+	}
+	for !b.exhausted() {
+		sort.Slice(simplex, func(i, j int) bool { return simplex[i].rev > simplex[j].rev })
+		if simplexSize(simplex, bounds) < minStep {
+			return
+		}
+		centroid := make([]float64, n)
+		for _, v := range simplex[:n] {
+			for i := range centroid {
+				centroid[i] += v.x[i] / float64(n)
+			}
+		}
+		worst := simplex[n]
+
+		reflected := along(centroid, worst.x, -1)
+		rRev := b.eval(reflected)
+		if b.exhausted() {
+			return // no evaluations left to expand or contract
+		}
+		switch {
+		case rRev > simplex[0].rev:
+			expanded := along(centroid, worst.x, -2)
+			if eRev := b.eval(expanded); eRev > rRev {
+				simplex[n] = vertex{expanded, eRev}
+			} else {
+				simplex[n] = vertex{reflected, rRev}
+			}
+		case rRev > simplex[n-1].rev:
+			simplex[n] = vertex{reflected, rRev}
+		default:
+			contracted := along(centroid, worst.x, 0.5)
+			if rRev > worst.rev {
+				contracted = along(centroid, worst.x, -0.5) // outside contraction
+			}
+			if cRev := b.eval(contracted); cRev > math.Max(rRev, worst.rev) {
+				simplex[n] = vertex{contracted, cRev}
+				break
+			}
+			for k := 1; k <= n && !b.exhausted(); k++ {
+				x := along(simplex[0].x, simplex[k].x, 0.5)
+				simplex[k] = vertex{x, b.eval(x)}
+			}
+		}
+	}
+}
+
+// Polish runs pattern search from prices until the budget is used or the step is negligible
+func (ps PatternSearch) Polish(prices []float64, pr pp.Problem, budget int) ([]float64, float64) {
+	b := newBudgeted(prices, pr, budget)
+	base, baseRev := append([]float64{}, b.bestPrices...), b.bestRevenue
+	step := ps.Step
+	for step > minStep && !b.exhausted() {
+		x, rev := ps.explore(b, base, baseRev, step)
+		if rev <= baseRev {
+			step /= 2
+			continue
+		}
+		// pattern move, continuing in the improving direction while it keeps improving
+		for rev > baseRev && !b.exhausted() {
+			pattern := make([]float64, len(x))
+			for i := range x {
+				pattern[i] = x[i] + (x[i] - base[i])
+			}
+			base, baseRev = x, rev
+			x, rev = ps.explore(b, pattern, b.eval(pattern), step)
+		}
+	}
+	return b.bestPrices, b.bestRevenue
+}
+
+// explore tries a step either side of each good in turn from x, keeping any improvement
+func (ps PatternSearch) explore(b *budgeted, x []float64, rev float64, step float64) ([]float64, float64) {
+	bounds := b.problem.Bounds()
+	x = append([]float64{}, x...)
+	for i := range x {
+		for _, dir := range []float64{1, -1} {
+			if b.exhausted() {
+				return x, rev
+			}
+			trial := append([]float64{}, x...)
+			trial[i] += dir * step * (bounds[i][1] - bounds[i][0])
+			if tRev := b.eval(trial); tRev > rev {
+				x, rev = trial, tRev
+				break
+			}
+		}
+	}
+	return x, rev
+}
+
+// Polish runs coordinate search from prices until the budget is used or the step is negligible
+func (cs CoordinateSearch) Polish(prices []float64, pr pp.Problem, budget int) ([]float64, float64) {
+	b := newBudgeted(prices, pr, budget)
+	bounds := pr.Bounds()
+	x, rev := append([]float64{}, b.bestPrices...), b.bestRevenue
+	step := cs.Step
+	for step > minStep && !b.exhausted() {
+		improved := false
+		for i := range x {
+			for _, dir := range []float64{1, -1} {
+				if b.exhausted() {
+					return b.bestPrices, b.bestRevenue
+				}
+				trial := append([]float64{}, x...)
+				trial[i] += dir * step * (bounds[i][1] - bounds[i][0])
+				if tRev := b.eval(trial); tRev > rev {
+					x, rev = trial, tRev
+					improved = true
+					break
+				}
+			}
+		}
+		if !improved {
+			step /= 2
+		}
+	}
+	return b.bestPrices, b.bestRevenue
+}
+
+// vertex is one evaluated point of a simplex
+type vertex struct {
+	x   []float64
+	rev float64
+}
+
+// simplexSize is the largest difference from the best vertex in any good, as a fraction of its bound range
+func simplexSize(simplex []vertex, bounds [][]float64) float64 {
+	var size float64
+	for _, v := range simplex[1:] {
+		for i := range v.x {
+			size = math.Max(size, math.Abs(v.x[i]-simplex[0].x[i])/(bounds[i][1]-bounds[i][0]))
+		}
+	}
+	return size
+}
@@ -1,10 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/aagoldingay/ci-cw-go/ais"
 	"github.com/aagoldingay/ci-cw-go/algorithms"
+	"github.com/aagoldingay/ci-cw-go/ga"
 	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+	"github.com/aagoldingay/ci-cw-go/pso"
 	"github.com/aagoldingay/ci-cw-go/xlsxhandler"
 )
 
@@ -21,27 +25,54 @@ func runSingle(numGoods int, seed int64) {
 	p := pp.PricingProblem{}
 	p = *p.MakeProblem(numGoods, seed, false) //courseworkInstance
 	// p = *p.MakeProblem(numGoods, seed, true) //randomInstance
+	// cfg, _ := pp.Preset("elastic-heavy") // "coursework", "elastic-heavy", "sparse-large"
+	// p = *p.Generate(cfg, seed, false)
+	// l := pp.LogitProblem{} // multinomial logit choice model, pass &l to the algorithms instead of &p
+	// l.MakeProblem(numGoods, seed, false)
 
-	rev, history := algorithms.RandomSearch(numGoods, true, &p) //numGoods
+	ctx := context.Background()
+	stop := algorithms.DefaultStop // e.g. algorithms.Any(algorithms.MaxSteps(100), algorithms.NoImprovement(20))
+
+	rev, history := algorithms.RandomSearch(ctx, stop, true, &p)
 	fmt.Printf("rev : %v\nall : %v\n", rev, history)
-	// algorithms.PSOSearch(numGoods, 25, false, &p) //numGoods, numParticles
-	// algorithms.AISSearch(numGoods, 30, 10, 5, false, &p) //numGoods, numPopulation, replacement, cloneSizeFactor
+	// algorithms.PSOSearch(ctx, numGoods, 25, pso.DefaultOptions(), stop, false, &p) //numGoods, numParticles, options
+	// algorithms.AISSearch(ctx, numGoods, 30, ais.DefaultOptions(), stop, false, &p) //numGoods, numPopulation, options
+	// algorithms.CMAESSearch(ctx, numGoods, cmaes.DefaultOptions(), stop, false, &p) //numGoods, options
+	// algorithms.DESearch(ctx, numGoods, 30, de.DefaultOptions(), stop, false, &p) //numGoods, numIndividuals, options
+	// algorithms.AINetSearch(ctx, ais.DefaultNetworkOptions(), stop, false, &p) //opt-aiNet, returns several diverse solutions
+	// algorithms.AnnealingSearch(ctx, localsearch.DefaultAnnealOptions(), stop, false, &p) //options
+	// algorithms.HillClimbSearch(ctx, localsearch.DefaultClimbOptions(), stop, false, &p) //options
+	// algorithms.CoordinateAscentSearch(ctx, localsearch.DefaultCoordinateOptions(), stop, false, &p) //options
+	// algorithms.BayesSearch(ctx, bayesopt.DefaultOptions(), nil, false, &p) //options, stop, nil stops after options.Budget evaluations
+	// algorithms.TabuSearch(ctx, tabu.DefaultOptions(), stop, false, &p) //options
+	// algorithms.MemeticSearch(ctx, pso.NewSwarm(numGoods, 25, pso.DefaultOptions(), nil), algorithms.DefaultMemeticOptions(), stop, false, &p) //population, options
 }
 
 func runAll(numGoods int, seeds []int64) {
 	// configurable algorithm parameters
 	psoPopulation := 20
+	psoOptions := pso.DefaultOptions()               // or pso.ConstrictionOptions()
+	psoOptions.InertiaSchedule = pso.ConstantInertia // ConstantInertia, LinearInertia, RandomInertia, ChaoticInertia
+	psoOptions.Boundary = pso.Clamp                  // Clamp, Reflect, RandomReinit, Wrap
+	psoOptions.Velocity = pso.ZeroVelocity           // ZeroVelocity, InvertVelocity, DampVelocity
 	aisPopulation := 20
-	aisReplacement := 10
-	aisClonesFactor := 8
+	aisOptions := ais.DefaultOptions()                // 10 replaced, clone size factor 8
+	aisOptions.Mutation = ais.Inversion{}             // Inversion, Gaussian, UniformReset, Polynomial, BlockResample
+	aisOptions.Normalisation = ais.FixedNormalisation // FixedNormalisation, BestNormalisation, MinMaxNormalisation, UpperBoundNormalisation
+	gaPopulation := 40
+	gaOptions := ga.DefaultOptions() // tournament selection, SBX crossover, polynomial mutation, 2 elites
+
+	stop := algorithms.DefaultStop // 3 second time limit
+	ctx := context.Background()
 
 	// revenue trackers
 	finalRevenues := [][]float64{}
 	randomRevenues := [][]float64{}
 	psoRevenues := [][]float64{}
 	aisRevenues := [][]float64{}
+	gaRevenues := [][]float64{}
 
-	for i := 0; i < 3; i++ { // 3 algorithms
+	for i := 0; i < 4; i++ { // 4 algorithms
 		finalRevenues = append(finalRevenues, make([]float64, len(seeds)))
 	}
 
@@ -51,23 +82,27 @@ func runAll(numGoods int, seeds []int64) {
 		// p = *p.MakeProblem(numGoods, true) //randomInstance
 
 		// data structures for returned list of revenues per step of each process (for xlsx printing)
-		var ran, pso, ais []float64
+		var ran, pso, ais, ga []float64
 
 		fmt.Printf("----------\nRandom Search\n----------\n")
-		finalRevenues[0][i], ran = algorithms.RandomSearch(numGoods, true, &p)
+		finalRevenues[0][i], ran = algorithms.RandomSearch(ctx, stop, true, &p)
 		randomRevenues = append(randomRevenues, ran)
 
 		fmt.Printf("----------\nPSO\n----------\n")
-		finalRevenues[1][i], pso = algorithms.PSOSearch(numGoods, psoPopulation, true, &p)
+		finalRevenues[1][i], pso = algorithms.PSOSearch(ctx, numGoods, psoPopulation, psoOptions, stop, true, &p)
 		psoRevenues = append(psoRevenues, pso)
 
 		fmt.Printf("----------\nAIS\n----------\n")
-		finalRevenues[2][i], ais = algorithms.AISSearch(numGoods, aisPopulation, aisReplacement, aisClonesFactor, true, &p) //numGoods, numPopulation, replacement, cloneSizeFactor
+		finalRevenues[2][i], ais = algorithms.AISSearch(ctx, numGoods, aisPopulation, aisOptions, stop, true, &p) //numGoods, numPopulation, options
 		aisRevenues = append(aisRevenues, ais)
+
+		fmt.Printf("----------\nGA\n----------\n")
+		finalRevenues[3][i], ga = algorithms.GASearch(ctx, numGoods, gaPopulation, gaOptions, stop, true, &p) //numGoods, numIndividuals, options
+		gaRevenues = append(gaRevenues, ga)
 	}
 	fmt.Printf("%v\n", finalRevenues)
 
 	// xlsx output
-	// xlsxhandler.WriteXLSXParams(finalRevenues, psoPopulation, aisPopulation, aisReplacement, aisClonesFactor)
-	xlsxhandler.WriteXLSXRevenues(seeds, randomRevenues, psoRevenues, aisRevenues)
+	// xlsxhandler.WriteXLSXParams(finalRevenues, psoPopulation, psoOptions, aisPopulation, aisOptions, gaPopulation, gaOptions)
+	xlsxhandler.WriteXLSXRevenues(seeds, randomRevenues, psoRevenues, aisRevenues, gaRevenues)
 }
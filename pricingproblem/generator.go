@@ -0,0 +1,175 @@
+package pricingproblem
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Range is a uniform distribution over [Min, Max)
+type Range struct {
+	Min, Max float64
+}
+
+// draw returns a random value from the range
+func (r Range) draw() float64 {
+	return r.Min + rand.Float64()*(r.Max-r.Min)
+}
+
+// GeneratorConfig controls the random generation of a PricingProblem
+type GeneratorConfig struct {
+	NumGoods int
+	// probability of each price response curve type, indexed by Linear, ConstantElasticity, FixedDemand
+	CurveProbabilities [3]float64
+	// distributions of the curve parameters
+	TotalDemand, SatiatingPrice, Elasticity Range
+	// distribution of the magnitude of each impact between goods
+	Impact Range
+	// ImpactDensity = probability that a pair of goods impact each other at all
+	// SubstituteRate = probability that an impact is negative (cannibalisation)
+	ImpactDensity, SubstituteRate float64
+	ImpactMode                    ImpactMode
+	// Segments = number of customer segments per good, 1 keeps a single curve
+	Segments int
+	// lower and upper price bound of every good
+	Bounds [2]float64
+}
+
+// Presets are named generator configurations, for building families of instances with controlled difficulty
+var Presets = map[string]GeneratorConfig{
+	// the instances used in the coursework
+	"coursework": {
+		NumGoods:           20,
+		CurveProbabilities: [3]float64{0.4, 0.5, 0.1},
+		TotalDemand:        Range{0, 100},
+		SatiatingPrice:     Range{0, 10},
+		Elasticity:         Range{0, 1},
+		Impact:             Range{0, 0.1},
+		ImpactDensity:      1.0,
+		Segments:           1,
+		Bounds:             [2]float64{0.01, 10.0},
+	},
+	// mostly constant elasticity goods, many elastic enough that revenue falls as price rises
+	"elastic-heavy": {
+		NumGoods:           20,
+		CurveProbabilities: [3]float64{0.1, 0.85, 0.05},
+		TotalDemand:        Range{20, 100},
+		SatiatingPrice:     Range{2, 10},
+		Elasticity:         Range{0.5, 2.5},
+		Impact:             Range{0, 0.1},
+		ImpactDensity:      0.5,
+		SubstituteRate:     0.3,
+		Segments:           1,
+		Bounds:             [2]float64{0.01, 10.0},
+	},
+	// a large catalogue where each good only interacts with a few others, but strongly
+	"sparse-large": {
+		NumGoods:           100,
+		CurveProbabilities: [3]float64{0.4, 0.5, 0.1},
+		TotalDemand:        Range{0, 100},
+		SatiatingPrice:     Range{0, 10},
+		Elasticity:         Range{0, 1},
+		Impact:             Range{0, 0.3},
+		ImpactDensity:      0.05,
+		SubstituteRate:     0.2,
+		Segments:           1,
+		Bounds:             [2]float64{0.01, 10.0},
+	},
+}
+
+// Preset returns the named generator configuration
+func Preset(name string) (GeneratorConfig, error) {
+	cfg, ok := Presets[name]
+	if !ok {
+		names := []string{}
+		for n := range Presets {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return GeneratorConfig{}, fmt.Errorf("PricingProblem::Preset unknown preset %q, expected one of %v", name, names)
+	}
+	return cfg, nil
+}
+
+// Generate instantiates a new PricingProblem from a generator configuration
+// random = whether to use a random seed, else seed is used
+func (p *PricingProblem) Generate(cfg GeneratorConfig, seed int64, random bool) *PricingProblem {
+	rand.Seed(time.Now().UnixNano()) // completely random
+	if !random {
+		rand.Seed(seed)
+	}
+	n := cfg.NumGoods
+	p.priceResponse = [][]float64{} // n by 2
+	for i := 0; i < n; i++ {
+		p.priceResponse = append(p.priceResponse, make([]float64, 2))
+	}
+	p.priceResponseType = make([]int, n)
+	p.impact = [][]float64{} // n by n
+	for i := 0; i < n; i++ {
+		p.impact = append(p.impact, make([]float64, n))
+	}
+	p.impactMode = cfg.ImpactMode
+
+	for i := 0; i < n; i++ {
+		t := rand.Float64()
+		if t <= cfg.CurveProbabilities[Linear] {
+			// Linear
+			p.priceResponseType[i] = Linear
+			p.priceResponse[i][0] = cfg.TotalDemand.draw()
+			p.priceResponse[i][1] = cfg.SatiatingPrice.draw()
+		} else if t < cfg.CurveProbabilities[Linear]+cfg.CurveProbabilities[ConstantElasticity] {
+			// Constant Elasticity
+			p.priceResponseType[i] = ConstantElasticity
+			p.priceResponse[i][0] = cfg.TotalDemand.draw()
+			p.priceResponse[i][1] = cfg.Elasticity.draw()
+		} else {
+			// Fixed Demand
+			p.priceResponseType[i] = FixedDemand
+			p.priceResponse[i][0] = cfg.TotalDemand.draw()
+		}
+
+		p.impact[i] = cfg.drawImpacts(n, i)
+	}
+	// each good starts as a single segment covering the whole market
+	p.segments = make([][]Segment, n)
+	for i := 0; i < n; i++ {
+		p.segments[i] = []Segment{{"all", p.priceResponseType[i], 1.0, p.priceResponse[i][1]}}
+	}
+	p.bnds = [][]float64{}
+	for i := 0; i < len(p.priceResponse); i++ {
+		p.bnds = append(p.bnds, make([]float64, 2))
+	}
+	dimBnd := []float64{cfg.Bounds[0], cfg.Bounds[1]}
+	for i := 0; i < len(p.priceResponse); i++ {
+		p.bnds[i] = dimBnd
+	}
+	if cfg.Segments > 1 {
+		p.splitSegments(cfg.Segments, cfg)
+	}
+	return p
+}
+
+// drawImpacts returns the random impacts of good i on each of n goods, none on itself
+// the impact on itself is drawn and discarded, so the coursework preset reproduces its original instances
+func (cfg GeneratorConfig) drawImpacts(n, i int) []float64 {
+	impacts := make([]float64, n)
+	for j := range impacts {
+		impacts[j] = cfg.drawImpact()
+	}
+	impacts[i] = 0.0
+	return impacts
+}
+
+// drawImpact returns a random impact between two goods
+// density and substitute draws are only made when needed, so the coursework preset reproduces its original instances
+func (cfg GeneratorConfig) drawImpact() float64 {
+	v := cfg.Impact.draw()
+	if cfg.ImpactDensity < 1 && rand.Float64() >= cfg.ImpactDensity {
+		return 0.0
+	}
+	if cfg.SubstituteRate > 0 && rand.Float64() < cfg.SubstituteRate {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,46 @@
+package pricingproblem
+
+// ImpactMode determines how the impact matrix creates residual demand between goods
+// a positive impact[j][i] makes good j a complement of good i, a negative impact makes it a substitute
+type ImpactMode int
+
+const (
+	// Additive adds impact[j][i] of good j's demand to the demand of good i
+	Additive ImpactMode = iota
+	// Multiplicative scales good i's demand by (price[j] / reference price)^-impact[j][i] for each other good j
+	// the reference price of a good is the middle of its bounds
+	Multiplicative
+)
+
+func (m ImpactMode) String() string {
+	switch m {
+	case Additive:
+		return "additive"
+	case Multiplicative:
+		return "multiplicative"
+	}
+	return "unknown"
+}
+
+// SetImpactMode selects how the impact matrix is applied when calculating residual demand
+func (p *PricingProblem) SetImpactMode(mode ImpactMode) *PricingProblem {
+	p.impactMode = mode
+	return p
+}
+
+// ImpactMode returns how the impact matrix is applied when calculating residual demand
+func (p *PricingProblem) ImpactMode() ImpactMode {
+	return p.impactMode
+}
+
+// GenerateImpacts replaces the impact matrix with random impacts between goods, drawn as by Generate
+// density = probability that a pair of goods impact each other at all
+// substituteRate = probability that an impact is negative (cannibalisation) rather than positive
+// scale = the largest magnitude of an impact, MakeProblem uses 0.1
+func (p *PricingProblem) GenerateImpacts(density, substituteRate, scale float64) *PricingProblem {
+	cfg := GeneratorConfig{Impact: Range{0, scale}, ImpactDensity: density, SubstituteRate: substituteRate}
+	for i := range p.impact {
+		p.impact[i] = cfg.drawImpacts(len(p.impact), i)
+	}
+	return p
+}
@@ -0,0 +1,65 @@
+package pricingproblem
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// goodJSON is the JSON representation of a single good
+type goodJSON struct {
+	TotalDemand float64   `json:"totalDemand"`
+	Bounds      []float64 `json:"bounds"`
+	Segments    []Segment `json:"segments"`
+}
+
+// problemJSON is the JSON representation of a PricingProblem
+type problemJSON struct {
+	Goods      []goodJSON  `json:"goods"`
+	Impact     [][]float64 `json:"impact"`
+	ImpactMode ImpactMode  `json:"impactMode"`
+}
+
+// MarshalJSON encodes the goods, their segments and the impact matrix of a PricingProblem
+func (p *PricingProblem) MarshalJSON() ([]byte, error) {
+	pj := problemJSON{Goods: make([]goodJSON, len(p.priceResponse)), Impact: p.impact, ImpactMode: p.impactMode}
+	for i := 0; i < len(p.priceResponse); i++ {
+		pj.Goods[i] = goodJSON{p.priceResponse[i][0], p.bnds[i], p.segments[i]}
+	}
+	return json.Marshal(pj)
+}
+
+// UnmarshalJSON decodes a PricingProblem previously encoded by MarshalJSON
+func (p *PricingProblem) UnmarshalJSON(data []byte) error {
+	var pj problemJSON
+	if err := json.Unmarshal(data, &pj); err != nil {
+		return err
+	}
+	n := len(pj.Goods)
+	if len(pj.Impact) != n {
+		return fmt.Errorf("PricingProblem::UnmarshalJSON impact matrix has %v rows for %v goods", len(pj.Impact), n)
+	}
+
+	p.priceResponseType = make([]int, n)
+	p.priceResponse = make([][]float64, n)
+	p.bnds = make([][]float64, n)
+	p.segments = make([][]Segment, n)
+	p.impact = pj.Impact
+	p.impactMode = pj.ImpactMode
+	for i, g := range pj.Goods {
+		if len(pj.Impact[i]) != n {
+			return fmt.Errorf("PricingProblem::UnmarshalJSON impact row %v has %v columns for %v goods", i, len(pj.Impact[i]), n)
+		}
+		if len(g.Bounds) != 2 || g.Bounds[0] > g.Bounds[1] {
+			return fmt.Errorf("PricingProblem::UnmarshalJSON good %v has invalid bounds %v", i, g.Bounds)
+		}
+		p.priceResponse[i] = []float64{g.TotalDemand, 0}
+		p.bnds[i] = g.Bounds
+		if err := p.SetSegments(i, g.Segments); err != nil {
+			return err
+		}
+		// the first segment describes the good when only one curve is reported
+		p.priceResponseType[i] = g.Segments[0].Type
+		p.priceResponse[i][1] = g.Segments[0].Param
+	}
+	return nil
+}
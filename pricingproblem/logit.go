@@ -0,0 +1,133 @@
+package pricingproblem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// LogitProblem is a pricing model in which each customer chooses one good, or no purchase,
+// under a multinomial logit: utility of good i = attractiveness[i] - sensitivity[i] * price[i], no purchase = 0
+// substitution between goods comes from the choice model, so there is no impact matrix
+type LogitProblem struct {
+	marketSize                  float64 // number of customers
+	attractiveness, sensitivity []float64
+	bnds                        [][]float64
+}
+
+// NewLogitProblem creates a LogitProblem from known parameters
+// every sensitivity must be positive, and bounds must hold a lower and upper price for each good
+func NewLogitProblem(marketSize float64, attractiveness, sensitivity []float64, bounds [][]float64) (*LogitProblem, error) {
+	if len(attractiveness) != len(sensitivity) || len(attractiveness) != len(bounds) {
+		return nil, errors.New("LogitProblem::NewLogitProblem parameters have different numbers of goods")
+	}
+	for i := 0; i < len(sensitivity); i++ {
+		if sensitivity[i] <= 0 {
+			return nil, fmt.Errorf("LogitProblem::NewLogitProblem good %v has non-positive price sensitivity", i)
+		}
+		if len(bounds[i]) != 2 || bounds[i][0] > bounds[i][1] {
+			return nil, fmt.Errorf("LogitProblem::NewLogitProblem good %v has invalid bounds %v", i, bounds[i])
+		}
+	}
+	return &LogitProblem{marketSize, attractiveness, sensitivity, bounds}, nil
+}
+
+// MakeProblem instantiates a new LogitProblem
+// n = number of Goods for the pricing problem
+// random = whether to use a random seed, else the given seed is used
+func (l *LogitProblem) MakeProblem(n int, seed int64, random bool) *LogitProblem {
+	rand.Seed(time.Now().UnixNano()) // completely random
+	if !random {
+		rand.Seed(seed)
+	}
+	l.marketSize = 100.0 * float64(n)
+	l.attractiveness = make([]float64, n)
+	l.sensitivity = make([]float64, n)
+	l.bnds = [][]float64{}
+	dimBnd := []float64{0.01, 10.0}
+	for i := 0; i < n; i++ {
+		l.attractiveness[i] = rand.Float64() * 2 // [0, 2)
+		l.sensitivity[i] = 0.5 + rand.Float64()  // [0.5, 1.5)
+		l.bnds = append(l.bnds, dimBnd)
+	}
+	return l
+}
+
+// Bounds returns the bnds variable of a LogitProblem struct
+func (l *LogitProblem) Bounds() [][]float64 {
+	return l.bnds
+}
+
+// IsValid checks whether a vector of prices is valid
+// A valid price vector is one in which all prices lie within their bounds
+func (l *LogitProblem) IsValid(prices []float64) bool {
+	if len(prices) != len(l.Bounds()) {
+		return false
+	}
+	for i := 0; i < len(prices); i++ {
+		if prices[i] < l.Bounds()[i][0] || prices[i] > l.Bounds()[i][1] {
+			return false
+		}
+	}
+	return true
+}
+
+// Evaluate gets the total revenue from pricing goods as given in parameter
+func (l *LogitProblem) Evaluate(prices []float64) (float64, error) {
+	if len(prices) != len(l.Bounds()) {
+		return 0.0, errors.New("LogitProblem::evaluate called on price array of the wrong size")
+	}
+	if !l.IsValid(prices) {
+		return 0.0, nil
+	}
+	return math.Round(l.revenue(prices)*100.0) / 100.0, nil
+}
+
+// revenue is the unrounded expected revenue across the whole market
+func (l *LogitProblem) revenue(prices []float64) float64 {
+	total := 1.0 // no purchase option, exp(0)
+	weighted := 0.0
+	for i := 0; i < len(prices); i++ {
+		w := math.Exp(l.attractiveness[i] - l.sensitivity[i]*prices[i])
+		total += w
+		weighted += prices[i] * w
+	}
+	return l.marketSize * weighted / total
+}
+
+// Optimum returns the revenue maximising prices and revenue, ignoring bounds
+// For a single nest, every optimal price is 1/sensitivity[i] + r, where r is the optimal revenue per customer
+// and the unique root of r = sum over i of exp(attractiveness[i] - 1 - sensitivity[i] * r) / sensitivity[i]
+// an error is returned if the optimum lies outside of the bounds
+func (l *LogitProblem) Optimum() ([]float64, float64, error) {
+	f := func(r float64) float64 {
+		var sum float64
+		for i := 0; i < len(l.sensitivity); i++ {
+			sum += math.Exp(l.attractiveness[i]-1-l.sensitivity[i]*r) / l.sensitivity[i]
+		}
+		return sum - r
+	}
+
+	// f is strictly decreasing, with f(0) > 0 and f(f(0)) <= 0, so bisect for the root
+	lo, hi := 0.0, f(0)
+	for i := 0; i < 200 && hi-lo > 1e-12; i++ {
+		mid := (lo + hi) / 2
+		if f(mid) > 0 {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	r := (lo + hi) / 2
+
+	prices := make([]float64, len(l.sensitivity))
+	for i := 0; i < len(prices); i++ {
+		prices[i] = 1/l.sensitivity[i] + r
+	}
+	if !l.IsValid(prices) {
+		return prices, l.marketSize * r, fmt.Errorf("LogitProblem::Optimum lies outside of the bounds : %v", prices)
+	}
+	return prices, l.marketSize * r, nil
+}
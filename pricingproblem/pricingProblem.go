@@ -4,71 +4,23 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"math/rand"
-	"time"
 )
 
 // PricingProblem contains information about prices and
 type PricingProblem struct {
 	priceResponseType           []int
 	priceResponse, impact, bnds [][]float64
+	segments                    [][]Segment // customer segments of each good, demands sum before the market cap
+	impactMode                  ImpactMode  // how the impact matrix turns other goods into residual demand
 }
 
-// MakeProblem instantiates a new PricingProblem
+// MakeProblem instantiates a new PricingProblem using the "coursework" generator preset
 // n = number of Goods for the pricing problem
 // random = whether to use a random seed, else seed = 0
 func (p *PricingProblem) MakeProblem(n int, seed int64, random bool) *PricingProblem {
-	rand.Seed(time.Now().UnixNano()) // completely random
-	if !random {
-		rand.Seed(seed)
-	}
-	p.priceResponse = [][]float64{} // n by 2
-	for i := 0; i < n; i++ {
-		p.priceResponse = append(p.priceResponse, make([]float64, 2))
-	}
-	p.priceResponseType = make([]int, n)
-	p.impact = [][]float64{} // n by n
-	for i := 0; i < n; i++ {
-		p.impact = append(p.impact, make([]float64, n))
-	}
-
-	for i := 0; i < n; i++ {
-		fmt.Printf("Setting up good {%v} with type: ", i)
-		t := rand.Float64()
-		if t <= 0.4 {
-			// Linear
-			p.priceResponseType[i] = 0
-			p.priceResponse[i][0] = p.getRandomTotalDemand()
-			p.priceResponse[i][1] = p.getRandomSatiatingPrice()
-			fmt.Printf("L (%v/%v)\n", p.priceResponse[i][0], p.priceResponse[i][1])
-
-		} else if t > 0.4 && t < 0.9 {
-			// Constant Elasticity
-			p.priceResponseType[i] = 1
-			p.priceResponse[i][0] = p.getRandomTotalDemand()
-			p.priceResponse[i][1] = p.getRandomElasticity()
-			fmt.Printf("CE (%v/%v)\n", p.priceResponse[i][0], p.priceResponse[i][1])
-		} else {
-			// Fixed Demand
-			p.priceResponseType[i] = 2
-			p.priceResponse[i][0] = p.getRandomTotalDemand()
-			fmt.Printf("FD (%v/%v)\n", p.priceResponse[i][0], p.priceResponse[i][1])
-		}
-
-		for j := 0; j < n; j++ {
-			p.impact[i][j] = rand.Float64() * 0.1
-		}
-		p.impact[i][i] = 0.0
-	}
-	p.bnds = [][]float64{}
-	for i := 0; i < len(p.priceResponse); i++ {
-		p.bnds = append(p.bnds, make([]float64, 2))
-	}
-	dimBnd := []float64{0.01, 10.0}
-	for i := 0; i < len(p.priceResponse); i++ {
-		p.bnds[i] = dimBnd
-	}
-	return p
+	cfg := Presets["coursework"]
+	cfg.NumGoods = n
+	return p.Generate(cfg, seed, random)
 }
 
 // Bounds returns the bnds variable of a PricingProblem struct
@@ -77,7 +29,7 @@ func (p *PricingProblem) Bounds() [][]float64 {
 }
 
 // IsValid checks whether a vector of prices is valid
-// A valid price vector is one in which all prices are at least 1p and at most £10.00
+// A valid price vector is one in which all prices lie within their bounds, by default at least 1p and at most £10.00
 func (p *PricingProblem) IsValid(prices []float64) bool {
 	if len(prices) != len(p.Bounds()) {
 		return false
@@ -115,55 +67,75 @@ func (p *PricingProblem) getDemand(i int, prices []float64) int {
 	if float64(demand) > p.priceResponse[i][0] {
 		demand = int(math.Round(p.priceResponse[i][0]))
 	}
+	// or less than 0 demand, as substitutes can remove more demand than the good has
+	if demand < 0 {
+		demand = 0
+	}
 	return demand
 }
 
+// get the demand for good i at price, summed across all of its segments
 func (p *PricingProblem) getGoodDemand(i int, price float64) int {
 	var demand float64
-	switch p.priceResponseType[i] {
-	case 0: // Linear
-		demand = p.priceResponse[i][0] - ((p.priceResponse[i][0] / p.priceResponse[i][1]) * price)
+	for _, s := range p.segments[i] {
+		demand += p.getSegmentDemand(i, s, price)
+	}
+
+	// Sanity check - segments together cannot have more demand than market holds
+	if demand > p.priceResponse[i][0] {
+		demand = math.Round(p.priceResponse[i][0])
+	}
+	return int(math.Round(demand))
+}
+
+// get the demand of one segment of good i at price
+func (p *PricingProblem) getSegmentDemand(i int, s Segment, price float64) float64 {
+	var demand float64
+	market := p.priceResponse[i][0] * s.Share
+	switch s.Type {
+	case Linear:
+		demand = market - ((market / s.Param) * price)
 		break
-	case 1: // Constant Elasticity
-		demand = p.priceResponse[i][0] / (math.Pow(price, p.priceResponse[i][1]))
+	case ConstantElasticity:
+		demand = market / (math.Pow(price, s.Param))
 		break
-	case 2: // Fixed Demand
-		demand = p.priceResponse[i][0]
+	case FixedDemand:
+		demand = market
 		break
 	default:
 		fmt.Println("Error! Incorrect price response curve specified")
 	}
 
-	// Sanity checks - cannot have more demand than market holds
-	if demand > p.priceResponse[i][0] {
-		demand = math.Round(p.priceResponse[i][0])
+	// Sanity checks - cannot have more demand than segment holds
+	if demand > market {
+		demand = math.Round(market)
 	}
 	// or less than 0 demand
 	if demand < 0 {
 		demand = 0
 	}
-	return int(math.Round(demand))
+	return demand
 }
 
+// get the demand for good i gained (or lost, for substitutes) due to the prices of other goods
 func (p *PricingProblem) getResidualDemand(i int, prices []float64) int {
 	var demand float64
-	for j := 0; j < len(p.priceResponse); j++ {
-		if i != j {
-			demand += float64(p.getGoodDemand(j, prices[j])) * p.impact[j][i]
+	switch p.impactMode {
+	case Additive: // a share of each other good's demand
+		for j := 0; j < len(p.priceResponse); j++ {
+			if i != j {
+				demand += float64(p.getGoodDemand(j, prices[j])) * p.impact[j][i]
+			}
+		}
+	case Multiplicative: // cross-price elasticity, relative to the middle of each good's bounds
+		factor := 1.0
+		for j := 0; j < len(p.priceResponse); j++ {
+			if i != j && p.impact[j][i] != 0 {
+				reference := (p.bnds[j][0] + p.bnds[j][1]) / 2
+				factor *= math.Pow(prices[j]/reference, -p.impact[j][i])
+			}
 		}
+		demand = float64(p.getGoodDemand(i, prices[i])) * (factor - 1)
 	}
 	return int(math.Round(demand))
 }
-
-func (p *PricingProblem) getRandomTotalDemand() float64 {
-	return rand.Float64() * 100
-}
-
-// get random desirable price
-func (p *PricingProblem) getRandomSatiatingPrice() float64 {
-	return rand.Float64() * 10
-}
-
-func (p *PricingProblem) getRandomElasticity() float64 {
-	return rand.Float64()
-}
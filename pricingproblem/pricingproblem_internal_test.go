@@ -0,0 +1,379 @@
+package pricingproblem
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func Test_logitOptimum(t *testing.T) {
+	l := LogitProblem{}
+	pr := l.MakeProblem(5, 0, false)
+	prices, revenue, err := pr.Optimum()
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	if math.Abs(pr.revenue(prices)-revenue) > 1e-6 {
+		t.Errorf("closed form revenue %v does not match evaluated revenue %v", revenue, pr.revenue(prices))
+	}
+
+	// no nearby or random prices should beat the optimum
+	for i := 0; i < 1000; i++ {
+		other := make([]float64, len(prices))
+		for j := 0; j < len(prices); j++ {
+			other[j] = prices[j] + rand.NormFloat64()*0.5
+			if i%2 == 0 {
+				other[j] = pr.bnds[j][0] + rand.Float64()*(pr.bnds[j][1]-pr.bnds[j][0])
+			}
+		}
+		if pr.IsValid(other) && pr.revenue(other) > revenue+1e-9 {
+			t.Fatalf("prices %v revenue %v beat optimum %v", other, pr.revenue(other), revenue)
+		}
+	}
+}
+
+func Test_logitEqualSensitivity(t *testing.T) {
+	// with equal sensitivity b, r = W(sum(exp(a - 1))) / b, for the Lambert W function
+	// two goods with a = 1 + ln(0.5) gives sum(exp(a - 1)) = 1, and W(1) = 0.567143290409784
+	a := 1 + math.Log(0.5)
+	pr, err := NewLogitProblem(1, []float64{a, a}, []float64{1, 1}, [][]float64{{0.01, 10}, {0.01, 10}})
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	prices, revenue, err := pr.Optimum()
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	if math.Abs(revenue-0.567143290409784) > 1e-9 {
+		t.Errorf("expected revenue %v, actual %v", 0.567143290409784, revenue)
+	}
+	if math.Abs(prices[0]-1.567143290409784) > 1e-9 || prices[0] != prices[1] {
+		t.Errorf("unexpected optimal prices : %v", prices)
+	}
+}
+
+// twoGoods returns a problem with a fixed and linear segment for good 0, and a fixed demand good 1
+// which adds a tenth of its demand to good 0
+func twoGoods() *PricingProblem {
+	p := &PricingProblem{
+		priceResponseType: []int{FixedDemand, FixedDemand},
+		priceResponse:     [][]float64{{10, 0}, {20, 0}},
+		impact:            [][]float64{{0, 0}, {0.1, 0}},
+		bnds:              [][]float64{{0.01, 10}, {0.01, 10}},
+		segments: [][]Segment{
+			{{"loyal", FixedDemand, 0.5, 0}, {"sensitive", Linear, 0.5, 10}},
+			{{"all", FixedDemand, 1, 0}},
+		},
+	}
+	return p
+}
+
+func Test_setSegments(t *testing.T) {
+	tests := []struct {
+		name     string
+		good     int
+		segments []Segment
+		valid    bool
+	}{
+		{"valid", 0, []Segment{{"a", Linear, 0.25, 5}, {"b", ConstantElasticity, 0.75, 0.5}}, true},
+		{"missing good", 2, []Segment{{"a", FixedDemand, 1, 0}}, false},
+		{"negative good", -1, []Segment{{"a", FixedDemand, 1, 0}}, false},
+		{"no segments", 0, []Segment{}, false},
+		{"unknown type", 0, []Segment{{"a", 3, 1, 0}}, false},
+		{"shares under 1", 0, []Segment{{"a", FixedDemand, 0.5, 0}, {"b", FixedDemand, 0.4, 0}}, false},
+		{"shares over 1", 0, []Segment{{"a", FixedDemand, 0.7, 0}, {"b", FixedDemand, 0.4, 0}}, false},
+	}
+	for _, tt := range tests {
+		p := twoGoods()
+		before := p.Segments(0)
+		err := p.SetSegments(tt.good, tt.segments)
+		if tt.valid {
+			if err != nil {
+				t.Errorf("%v : unexpected error : %v", tt.name, err)
+				continue
+			}
+			tt.segments[0].Share = 0 // the problem keeps its own copy
+			if s := p.Segments(tt.good); len(s) != 2 || s[0].Share != 0.25 {
+				t.Errorf("%v : segments not replaced by a copy : %v", tt.name, s)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("%v : expected an error", tt.name)
+		}
+		if s := p.Segments(0); len(s) != len(before) || s[0] != before[0] {
+			t.Errorf("%v : rejected segments changed good 0 : %v", tt.name, s)
+		}
+	}
+}
+
+func Test_jsonRoundTrip(t *testing.T) {
+	p := PricingProblem{}
+	p.MakeProblem(5, 0, false).SplitSegments(3).SetImpactMode(Multiplicative)
+	p.impact[1][2] = -0.05 // a substitute
+	data, err := json.Marshal(&p)
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	var q PricingProblem
+	if err := json.Unmarshal(data, &q); err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+
+	if q.ImpactMode() != Multiplicative {
+		t.Errorf("impact mode %v not restored", q.ImpactMode())
+	}
+	for i := 0; i < 5; i++ {
+		if !reflect.DeepEqual(p.Segments(i), q.Segments(i)) {
+			t.Errorf("good %v segments %v, expected %v", i, q.Segments(i), p.Segments(i))
+		}
+	}
+	for k := 0; k < 100; k++ {
+		prices := make([]float64, 5)
+		for i := range prices {
+			prices[i] = 0.01 + rand.Float64()*9.99
+		}
+		a, _ := p.Evaluate(prices)
+		b, _ := q.Evaluate(prices)
+		if a != b {
+			t.Fatalf("prices %v earn %v after the round trip, expected %v", prices, b, a)
+		}
+	}
+
+	if err := q.UnmarshalJSON([]byte(`{"goods":[{"totalDemand":1,"bounds":[0.01,10],"segments":[]}],"impact":[[0]]}`)); err == nil {
+		t.Errorf("expected an error for a good without segments")
+	}
+	if err := q.UnmarshalJSON([]byte(`{"goods":[{"totalDemand":1,"bounds":[0.01,10],"segments":[{"type":2,"share":1}]}],"impact":[]}`)); err == nil {
+		t.Errorf("expected an error for a missing impact matrix")
+	}
+}
+
+func Test_breakdown(t *testing.T) {
+	p := twoGoods()
+	goods, err := p.Breakdown([]float64{5, 2})
+	if err != nil {
+		t.Fatalf("unexpected error : %v", err)
+	}
+	// good 0 : 5 loyal + round(5 - 5/10 * 5) sensitive customers, and a tenth of good 1's 20
+	expected := []GoodRevenue{
+		{Price: 5, Demand: 10, Revenue: 50, Residual: 10, Segments: []SegmentRevenue{{"loyal", 5, 25}, {"sensitive", 2.5, 12.5}}},
+		{Price: 2, Demand: 20, Revenue: 40, Residual: 0, Segments: []SegmentRevenue{{"all", 20, 40}}},
+	}
+	if !reflect.DeepEqual(goods, expected) {
+		t.Errorf("expected breakdown %v, actual %v", expected, goods)
+	}
+	if rev, _ := p.Evaluate([]float64{5, 2}); rev != goods[0].Revenue+goods[1].Revenue {
+		t.Errorf("breakdown revenues do not sum to evaluated revenue %v", rev)
+	}
+
+	if goods, _ := p.Breakdown([]float64{5, 20}); goods[0].Revenue != 0 || goods[1].Segments != nil {
+		t.Errorf("invalid prices should earn nothing : %v", goods)
+	}
+	if _, err := p.Breakdown([]float64{5}); err == nil {
+		t.Errorf("expected an error for prices of the wrong size")
+	}
+}
+
+func Test_generateImpacts(t *testing.T) {
+	p := PricingProblem{}
+	p.MakeProblem(10, 0, false)
+	tests := []struct {
+		name                                string
+		density, substituteRate             float64
+		complements, substitutes, unrelated bool // whether any are expected
+	}{
+		{"complements", 1, 0, true, false, false},
+		{"substitutes", 1, 1, false, true, false},
+		{"unrelated", 0, 0.5, false, false, true},
+		{"mixed", 0.5, 0.5, true, true, true},
+	}
+	for _, tt := range tests {
+		p.GenerateImpacts(tt.density, tt.substituteRate, 0.2)
+		var complements, substitutes, unrelated bool // whether any were generated
+		for i := range p.impact {
+			for j, v := range p.impact[i] {
+				switch {
+				case i == j && v != 0:
+					t.Errorf("%v : good %v impacts itself", tt.name, i)
+				case v > 0.2 || v < -0.2:
+					t.Errorf("%v : impact %v larger than the scale", tt.name, v)
+				case v > 0:
+					complements = true
+				case v < 0:
+					substitutes = true
+				case i != j:
+					unrelated = true
+				}
+			}
+		}
+		if complements != tt.complements || substitutes != tt.substitutes || unrelated != tt.unrelated {
+			t.Errorf("%v : complements %v, substitutes %v, unrelated %v", tt.name, complements, substitutes, unrelated)
+		}
+	}
+
+	// the same seed gives the same impacts
+	a, b := PricingProblem{}, PricingProblem{}
+	a.MakeProblem(5, 0, false)
+	b.MakeProblem(5, 0, false)
+	rand.Seed(1)
+	a.GenerateImpacts(0.5, 0.5, 0.1)
+	rand.Seed(1)
+	b.GenerateImpacts(0.5, 0.5, 0.1)
+	if !reflect.DeepEqual(a.impact, b.impact) {
+		t.Errorf("impacts differ for the same seed")
+	}
+}
+
+func Test_residualDemand(t *testing.T) {
+	// good 0 has 8 customers of its own at a price of 5, and good 1 has 20
+	tests := []struct {
+		name     string
+		mode     ImpactMode
+		impact   float64 // of good 1 on good 0
+		prices   []float64
+		expected int // demand for good 0
+	}{
+		{"complement", Additive, 0.1, []float64{5, 2}, 10},
+		{"complement capped at the market", Additive, 0.5, []float64{5, 2}, 10},
+		{"substitute", Additive, -0.1, []float64{5, 2}, 6},
+		{"substitute floored at zero", Additive, -1, []float64{5, 2}, 0},
+		// (6.25625 / 5.005)^-1 = 0.8, removing a fifth of 8
+		{"multiplicative above the reference", Multiplicative, 1, []float64{5, 6.25625}, 6},
+		{"multiplicative at the reference", Multiplicative, 1, []float64{5, 5.005}, 8},
+		{"multiplicative capped at the market", Multiplicative, 1, []float64{5, 0.01}, 10},
+	}
+	for _, tt := range tests {
+		p := twoGoods().SetImpactMode(tt.mode)
+		p.impact[1][0] = tt.impact
+		if d := p.getDemand(0, tt.prices); d != tt.expected {
+			t.Errorf("%v : expected demand %v, actual %v", tt.name, tt.expected, d)
+		}
+		if d := p.getDemand(1, tt.prices); d != 20 {
+			t.Errorf("%v : good 1 has no impacts, but demand changed to %v", tt.name, d)
+		}
+	}
+}
+
+// baselineProblem draws the price responses and impacts of n goods exactly as the original MakeProblem did
+func baselineProblem(n int, seed int64) ([]int, [][]float64, [][]float64) {
+	rand.Seed(seed)
+	types := make([]int, n)
+	responses := make([][]float64, n)
+	impact := make([][]float64, n)
+	for i := 0; i < n; i++ {
+		responses[i] = make([]float64, 2)
+		t := rand.Float64()
+		if t <= 0.4 {
+			types[i] = 0
+			responses[i][0] = rand.Float64() * 100
+			responses[i][1] = rand.Float64() * 10
+		} else if t > 0.4 && t < 0.9 {
+			types[i] = 1
+			responses[i][0] = rand.Float64() * 100
+			responses[i][1] = rand.Float64()
+		} else {
+			types[i] = 2
+			responses[i][0] = rand.Float64() * 100
+		}
+		impact[i] = make([]float64, n)
+		for j := 0; j < n; j++ {
+			impact[i][j] = rand.Float64() * 0.1
+		}
+		impact[i][i] = 0.0
+	}
+	return types, responses, impact
+}
+
+func Test_courseworkPreset(t *testing.T) {
+	for _, seed := range []int64{0, 1, 42} {
+		types, responses, impact := baselineProblem(20, seed)
+		cfg, err := Preset("coursework")
+		if err != nil {
+			t.Fatalf("unexpected error : %v", err)
+		}
+		generated := PricingProblem{}
+		generated.Generate(cfg, seed, false)
+		made := PricingProblem{}
+		made.MakeProblem(20, seed, false)
+		for _, p := range []PricingProblem{generated, made} {
+			if !reflect.DeepEqual(p.priceResponseType, types) || !reflect.DeepEqual(p.priceResponse, responses) ||
+				!reflect.DeepEqual(p.impact, impact) {
+				t.Fatalf("seed %v does not reproduce the original instance", seed)
+			}
+			if p.impactMode != Additive || len(p.bnds) != 20 || p.bnds[0][0] != 0.01 || p.bnds[0][1] != 10 {
+				t.Errorf("seed %v : unexpected impact mode %v or bounds %v", seed, p.impactMode, p.bnds[0])
+			}
+		}
+	}
+
+	if _, err := Preset("unknown"); err == nil {
+		t.Errorf("expected an error for an unknown preset")
+	}
+}
+
+func Test_generateSettings(t *testing.T) {
+	cfg := Presets["coursework"]
+	cfg.NumGoods = 30
+	cfg.ImpactDensity = 0.3
+	cfg.SubstituteRate = 0.4
+	cfg.ImpactMode = Multiplicative
+	cfg.Segments = 3
+	cfg.Bounds = [2]float64{1, 5}
+	p := PricingProblem{}
+	p.Generate(cfg, 0, false)
+
+	if len(p.Bounds()) != 30 || p.Bounds()[29][0] != 1 || p.Bounds()[29][1] != 5 {
+		t.Errorf("unexpected bounds %v", p.Bounds())
+	}
+	if p.ImpactMode() != Multiplicative {
+		t.Errorf("unexpected impact mode %v", p.ImpactMode())
+	}
+	var related, substitutes int
+	for i := range p.impact {
+		for j, v := range p.impact[i] {
+			if i != j && v != 0 {
+				related++
+			}
+			if v < 0 {
+				substitutes++
+			}
+		}
+	}
+	if density := float64(related) / (30 * 29); math.Abs(density-0.3) > 0.05 {
+		t.Errorf("impact density %v, expected about 0.3", density)
+	}
+	if rate := float64(substitutes) / float64(related); math.Abs(rate-0.4) > 0.08 {
+		t.Errorf("substitute rate %v, expected about 0.4", rate)
+	}
+	for i := 0; i < 30; i++ {
+		segments := p.Segments(i)
+		if len(segments) != 3 {
+			t.Fatalf("good %v has %v segments, expected 3", i, len(segments))
+		}
+		var share float64
+		for _, s := range segments {
+			share += s.Share
+		}
+		if math.Abs(share-1) > 1e-9 {
+			t.Errorf("good %v segment shares sum to %v", i, share)
+		}
+		if err := p.SetSegments(i, segments); err != nil {
+			t.Errorf("good %v generated invalid segments : %v", i, err)
+		}
+	}
+
+	cfg.ImpactDensity, cfg.Segments = 0, 1
+	p.Generate(cfg, 0, false)
+	for i := range p.impact {
+		for _, v := range p.impact[i] {
+			if v != 0 {
+				t.Fatalf("impact %v generated with zero density", v)
+			}
+		}
+		if len(p.Segments(i)) != 1 {
+			t.Errorf("good %v has %v segments, expected 1", i, len(p.Segments(i)))
+		}
+	}
+}
@@ -0,0 +1,12 @@
+package pricingproblem
+
+// Problem is a market pricing model that the optimisation algorithms can search
+// PricingProblem and LogitProblem both satisfy Problem
+type Problem interface {
+	// Evaluate gets the total revenue from pricing goods as given in parameter
+	Evaluate(prices []float64) (float64, error)
+	// Bounds returns the lower and upper price bound of each good
+	Bounds() [][]float64
+	// IsValid checks whether every price lies within its bounds
+	IsValid(prices []float64) bool
+}
@@ -0,0 +1,135 @@
+package pricingproblem
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// price response curve types
+const (
+	Linear             = 0 // Param is the satiating price
+	ConstantElasticity = 1 // Param is the elasticity
+	FixedDemand        = 2 // Param is unused
+)
+
+// Segment models a group of customers for one good, such as price-sensitive or loyal customers
+// Share is the proportion of the good's total demand held by the segment
+type Segment struct {
+	Name  string  `json:"name"`
+	Type  int     `json:"type"`
+	Share float64 `json:"share"`
+	Param float64 `json:"param"`
+}
+
+// SegmentRevenue is the demand and revenue generated by one segment of a good
+type SegmentRevenue struct {
+	Name            string
+	Demand, Revenue float64
+}
+
+// GoodRevenue is the revenue generated by one good, split into its segments
+// Residual is the revenue from demand created by the impact of other goods
+// segment demands are before rounding and the market cap, so may not sum exactly to Demand
+type GoodRevenue struct {
+	Price, Demand, Revenue, Residual float64
+	Segments                         []SegmentRevenue
+}
+
+// Segments returns the customer segments of good i
+func (p *PricingProblem) Segments(i int) []Segment {
+	return p.segments[i]
+}
+
+// SetSegments replaces the customer segments of good i
+// the shares of all segments must sum to 1
+func (p *PricingProblem) SetSegments(i int, segments []Segment) error {
+	if i < 0 || i >= len(p.segments) {
+		return fmt.Errorf("PricingProblem::SetSegments good %v does not exist", i)
+	}
+	if len(segments) == 0 {
+		return errors.New("PricingProblem::SetSegments requires at least one segment")
+	}
+	var share float64
+	for _, s := range segments {
+		if s.Type < Linear || s.Type > FixedDemand {
+			return fmt.Errorf("PricingProblem::SetSegments unknown price response type %v", s.Type)
+		}
+		share += s.Share
+	}
+	if math.Abs(share-1.0) > 1e-9 {
+		return fmt.Errorf("PricingProblem::SetSegments shares sum to %v, not 1", share)
+	}
+	p.segments[i] = append([]Segment{}, segments...)
+	return nil
+}
+
+// SplitSegments divides the demand of every good between numSegments randomly generated segments
+// each segment is given a random share, price response type and parameter, as in MakeProblem
+func (p *PricingProblem) SplitSegments(numSegments int) *PricingProblem {
+	if numSegments < 2 {
+		return p
+	}
+	return p.splitSegments(numSegments, Presets["coursework"])
+}
+
+// splitSegments generates segment types and parameters from the distributions in cfg
+func (p *PricingProblem) splitSegments(numSegments int, cfg GeneratorConfig) *PricingProblem {
+	for i := 0; i < len(p.segments); i++ {
+		segments := make([]Segment, numSegments)
+		var total float64
+		for j := 0; j < numSegments; j++ {
+			segments[j] = randomSegment(fmt.Sprintf("segment %v", j), cfg)
+			total += segments[j].Share
+		}
+		for j := 0; j < numSegments; j++ {
+			segments[j].Share /= total // normalise shares to sum to 1
+		}
+		p.segments[i] = segments
+	}
+	return p
+}
+
+// randomSegment generates a segment with an unnormalised random share
+func randomSegment(name string, cfg GeneratorConfig) Segment {
+	s := Segment{Name: name, Share: rand.Float64()}
+	t := rand.Float64()
+	if t <= cfg.CurveProbabilities[Linear] {
+		s.Type = Linear
+		s.Param = cfg.SatiatingPrice.draw()
+	} else if t < cfg.CurveProbabilities[Linear]+cfg.CurveProbabilities[ConstantElasticity] {
+		s.Type = ConstantElasticity
+		s.Param = cfg.Elasticity.draw()
+	} else {
+		s.Type = FixedDemand
+	}
+	return s
+}
+
+// Breakdown reports the demand and revenue of each good, and of each segment of that good
+func (p *PricingProblem) Breakdown(prices []float64) ([]GoodRevenue, error) {
+	if len(prices) != len(p.Bounds()) {
+		return nil, errors.New("PricingProblem::Breakdown called on price array of the wrong size")
+	}
+	goods := make([]GoodRevenue, len(prices))
+	if !p.IsValid(prices) {
+		return goods, nil
+	}
+	for i := 0; i < len(prices); i++ {
+		demand := float64(p.getDemand(i, prices))
+		own := float64(p.getGoodDemand(i, prices[i]))
+		goods[i] = GoodRevenue{
+			Price:    prices[i],
+			Demand:   demand,
+			Revenue:  demand * prices[i],
+			Residual: (demand - own) * prices[i],
+			Segments: make([]SegmentRevenue, len(p.segments[i])),
+		}
+		for j, s := range p.segments[i] {
+			d := p.getSegmentDemand(i, s, prices[i])
+			goods[i].Segments[j] = SegmentRevenue{s.Name, d, d * prices[i]}
+		}
+	}
+	return goods, nil
+}
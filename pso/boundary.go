@@ -0,0 +1,150 @@
+package pso
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+)
+
+// BoundaryStrategy determines how a particle is repaired when it moves outside of the problem bounds
+type BoundaryStrategy int
+
+const (
+	// Clamp places the price on the bound that was crossed
+	Clamp BoundaryStrategy = iota
+	// Reflect mirrors the price back inside the bounds by the distance it overshot
+	Reflect
+	// RandomReinit draws a new random price within the bounds
+	RandomReinit
+	// Wrap treats the bounds as periodic, re-entering from the opposite bound
+	Wrap
+)
+
+// VelocityPolicy determines how the velocity of a repaired dimension is adjusted
+type VelocityPolicy int
+
+const (
+	// ZeroVelocity stops movement in the repaired dimension
+	ZeroVelocity VelocityPolicy = iota
+	// InvertVelocity reverses the direction of movement
+	InvertVelocity
+	// DampVelocity reverses the direction of movement and scales it by a random factor in [0, 1)
+	DampVelocity
+)
+
+// BoundaryHits counts how many times each good's lower and upper price bound was crossed
+type BoundaryHits struct {
+	Lower, Upper []int
+}
+
+// Total returns the sum of all lower and upper bound hits
+func (bh BoundaryHits) Total() int {
+	total := 0
+	for i := 0; i < len(bh.Lower); i++ {
+		total += bh.Lower[i] + bh.Upper[i]
+	}
+	return total
+}
+
+func (bh BoundaryHits) String() string {
+	return fmt.Sprintf("lower %v | upper %v | total %v", bh.Lower, bh.Upper, bh.Total())
+}
+
+func (b BoundaryStrategy) String() string {
+	switch b {
+	case Clamp:
+		return "clamp"
+	case Reflect:
+		return "reflect"
+	case RandomReinit:
+		return "random"
+	case Wrap:
+		return "wrap"
+	}
+	return "unknown"
+}
+
+func (v VelocityPolicy) String() string {
+	switch v {
+	case ZeroVelocity:
+		return "zero"
+	case InvertVelocity:
+		return "invert"
+	case DampVelocity:
+		return "damp"
+	}
+	return "unknown"
+}
+
+// boundaryHandler applies a BoundaryStrategy and VelocityPolicy, recording each bound crossed
+type boundaryHandler struct {
+	strategy BoundaryStrategy
+	velocity VelocityPolicy
+	hits     BoundaryHits
+}
+
+func newBoundaryHandler(numGoods int, strategy BoundaryStrategy, velocity VelocityPolicy) *boundaryHandler {
+	return &boundaryHandler{
+		strategy: strategy,
+		velocity: velocity,
+		hits:     BoundaryHits{make([]int, numGoods), make([]int, numGoods)},
+	}
+}
+
+// repair moves any out of bounds prices back inside bounds, adjusting velocity in place
+func (bh *boundaryHandler) repair(prices, velocity []float64, bounds [][]float64) {
+	for i := 0; i < len(prices); i++ {
+		lo, hi := bounds[i][0], bounds[i][1]
+		if prices[i] >= lo && prices[i] <= hi {
+			continue
+		}
+		if prices[i] < lo {
+			bh.hits.Lower[i]++
+		} else {
+			bh.hits.Upper[i]++
+		}
+
+		switch bh.strategy {
+		case Clamp:
+			prices[i] = math.Max(lo, math.Min(hi, prices[i]))
+		case Reflect:
+			prices[i] = reflect(prices[i], lo, hi)
+		case RandomReinit:
+			prices[i] = lo + rand.Float64()*(hi-lo)
+		case Wrap:
+			prices[i] = wrap(prices[i], lo, hi)
+		}
+
+		switch bh.velocity {
+		case ZeroVelocity:
+			velocity[i] = 0
+		case InvertVelocity:
+			velocity[i] = -velocity[i]
+		case DampVelocity:
+			velocity[i] = -rand.Float64() * velocity[i]
+		}
+	}
+}
+
+// reflect mirrors a price back inside [lo, hi]
+// a large overshoot can cross the opposite bound, so reflections repeat with period 2 * (hi - lo)
+func reflect(price, lo, hi float64) float64 {
+	width := hi - lo
+	if width <= 0 {
+		return lo // a fixed price has nowhere to reflect to
+	}
+	d := math.Mod(math.Abs(price-lo), 2*width)
+	if d > width {
+		d = 2*width - d
+	}
+	return lo + d
+}
+
+// wrap re-enters a price into [lo, hi) from the opposite bound
+func wrap(price, lo, hi float64) float64 {
+	width := hi - lo
+	if width <= 0 {
+		return lo // a fixed price has nowhere to wrap to
+	}
+	return lo + math.Mod(math.Mod(price-lo, width)+width, width)
+}
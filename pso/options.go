@@ -0,0 +1,229 @@
+package pso
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// InertiaSchedule determines how the inertia weighting changes as the swarm progresses
+type InertiaSchedule int
+
+const (
+	// ConstantInertia keeps Inertia for every step
+	ConstantInertia InertiaSchedule = iota
+	// LinearInertia decreases linearly from Inertia to InertiaMin over ScheduleSteps
+	LinearInertia
+	// RandomInertia draws a new inertia uniformly from [0.5, 1) each step (Eberhart & Shi)
+	RandomInertia
+	// ChaoticInertia decreases linearly from Inertia to InertiaMin over ScheduleSteps,
+	// with the final value perturbed by a logistic map (Feng et al.)
+	ChaoticInertia
+)
+
+func (s InertiaSchedule) String() string {
+	switch s {
+	case ConstantInertia:
+		return "constant"
+	case LinearInertia:
+		return "linear"
+	case RandomInertia:
+		return "random"
+	case ChaoticInertia:
+		return "chaotic"
+	}
+	return "unknown"
+}
+
+// Variant determines the rule each particle uses to move
+type Variant int
+
+const (
+	// Canonical moves with inertia towards the personal and neighbourhood best positions
+	Canonical Variant = iota
+	// BareBones samples each price from a Gaussian between the personal and neighbourhood best (Kennedy)
+	BareBones
+	// FIPS is the fully informed particle swarm, moving towards every informant's best position (Mendes et al.)
+	// it is Mendes' constricted form only with Constriction set, otherwise velocity is weighted by inertia
+	FIPS
+	// CLPSO is comprehensive learning, each price learns from the best of a different exemplar particle (Liang et al.)
+	CLPSO
+)
+
+// Variants lists every particle movement rule, for comparisons
+var Variants = []Variant{Canonical, BareBones, FIPS, CLPSO}
+
+func (v Variant) String() string {
+	switch v {
+	case Canonical:
+		return "canonical"
+	case BareBones:
+		return "bare-bones"
+	case FIPS:
+		return "fips"
+	case CLPSO:
+		return "clpso"
+	}
+	return "unknown"
+}
+
+// Options configures the movement of a Swarm
+type Options struct {
+	Inertia         float64 // (starting) weighting of momentum maintained between steps
+	InertiaMin      float64 // final inertia of LinearInertia and ChaoticInertia
+	InertiaSchedule InertiaSchedule
+	ScheduleSteps   int     // steps over which LinearInertia and ChaoticInertia decrease
+	Cognitive       float64 // weighting towards personal best position
+	Social          float64 // weighting towards global best position
+	// VMax clamps each dimension of velocity to this fraction of the good's bound range, 0 disables clamping
+	VMax float64
+	// VMaxDecay multiplies VMax after every step, 1 (or 0) keeps VMax constant
+	VMaxDecay float64
+	// RecordVelocity keeps the velocity magnitudes of every step in Swarm.VelocityStats, growing with the run
+	RecordVelocity bool
+	// Constriction uses Clerc's constriction factor in place of inertia when Cognitive + Social exceeds 4,
+	// otherwise there is no factor that damps velocity, so the inertia schedule is used instead
+	Constriction bool
+	Boundary     BoundaryStrategy
+	Velocity     VelocityPolicy
+	// Variant determines the movement rule, FIPS and CLPSO use Cognitive + Social and Cognitive as their learning weightings
+	Variant    Variant
+	RefreshGap int // steps without improving before a CLPSO particle picks new exemplars
+	// Topology determines which particles inform each other, see Topology for the neighbourhood parameters
+	Topology          Topology
+	Radius            int // particles either side of each particle in RingTopology
+	NeighbourhoodSize int // random informants of each particle in DynamicTopology
+	Patience          int // steps without improvement before DynamicTopology redraws neighbourhoods
+}
+
+// DefaultOptions returns the parameters used in the coursework
+func DefaultOptions() Options {
+	return Options{
+		Inertia:           0.721,
+		InertiaMin:        0.4,
+		InertiaSchedule:   ConstantInertia,
+		ScheduleSteps:     1000,
+		Cognitive:         1.2, // (default) 1.1193
+		Social:            1.1, // (default) 1.1193
+		Boundary:          Clamp,
+		Velocity:          ZeroVelocity,
+		Variant:           Canonical,
+		RefreshGap:        7,
+		Topology:          GlobalTopology,
+		Radius:            1,
+		NeighbourhoodSize: 3,
+		Patience:          10,
+	}
+}
+
+// ConstrictionOptions returns Clerc's constriction parameters, c1 = c2 = 2.05, giving a factor of ~0.7298
+func ConstrictionOptions() Options {
+	opts := DefaultOptions()
+	opts.Cognitive, opts.Social = 2.05, 2.05
+	opts.Constriction = true
+	return opts
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"inertia":   strconv.FormatFloat(o.Inertia, 'f', -1, 64),
+		"schedule":  o.InertiaSchedule.String(),
+		"cognitive": strconv.FormatFloat(o.Cognitive, 'f', -1, 64),
+		"social":    strconv.FormatFloat(o.Social, 'f', -1, 64),
+		"boundary":  o.Boundary.String(),
+		"velocity":  o.Velocity.String(),
+		"topology":  o.Topology.String(),
+		"variant":   o.Variant.String(),
+	}
+	if o.Variant == CLPSO {
+		params["refreshGap"] = strconv.Itoa(o.RefreshGap)
+	}
+	if o.VMax > 0 {
+		params["vmax"] = strconv.FormatFloat(o.VMax, 'f', -1, 64)
+		params["vmaxDecay"] = strconv.FormatFloat(o.VMaxDecay, 'f', -1, 64)
+	}
+	switch o.Topology {
+	case RingTopology:
+		params["radius"] = strconv.Itoa(o.Radius)
+	case DynamicTopology:
+		params["neighbourhoodSize"] = strconv.Itoa(o.NeighbourhoodSize)
+		params["patience"] = strconv.Itoa(o.Patience)
+	}
+	if o.constricted() {
+		// constriction replaces inertia and its schedule
+		params["constriction"] = strconv.FormatFloat(constrictionFactor(o.Cognitive+o.Social), 'f', -1, 64)
+		delete(params, "inertia")
+		delete(params, "schedule")
+	} else if o.InertiaSchedule == LinearInertia || o.InertiaSchedule == ChaoticInertia {
+		params["inertiaMin"] = strconv.FormatFloat(o.InertiaMin, 'f', -1, 64)
+		params["scheduleSteps"] = strconv.Itoa(o.ScheduleSteps)
+	}
+	return params
+}
+
+// coefficients are the weightings used for one step of velocity updates
+// new velocity = constriction * (inertia * velocity + cognitive * r1 * (pBest - x) + social * r2 * (gBest - x))
+// each dimension of the new velocity is then clamped to +/- vmax, unless vmax is nil
+type coefficients struct {
+	inertia, cognitive, social, constriction float64
+	vmax                                     []float64
+}
+
+// schedule tracks the state of an inertia schedule between steps
+type schedule struct {
+	step  int
+	chaos float64 // logistic map value for ChaoticInertia
+}
+
+// next returns the coefficients for the next step within bounds, advancing the schedule
+func (s *schedule) next(o Options, bounds [][]float64) coefficients {
+	c := coefficients{o.Inertia, o.Cognitive, o.Social, 1.0, nil}
+	if o.VMax > 0 {
+		fraction := o.VMax
+		if o.VMaxDecay > 0 {
+			fraction *= math.Pow(o.VMaxDecay, float64(s.step))
+		}
+		c.vmax = make([]float64, len(bounds))
+		for i := 0; i < len(bounds); i++ {
+			c.vmax[i] = fraction * (bounds[i][1] - bounds[i][0])
+		}
+	}
+	if o.constricted() {
+		c.inertia, c.constriction = 1.0, constrictionFactor(o.Cognitive+o.Social)
+		s.step++
+		return c
+	}
+
+	progress := 1.0
+	if o.ScheduleSteps > 0 {
+		progress = math.Min(float64(s.step)/float64(o.ScheduleSteps), 1.0)
+	}
+	switch o.InertiaSchedule {
+	case LinearInertia:
+		c.inertia = o.Inertia - (o.Inertia-o.InertiaMin)*progress
+	case RandomInertia:
+		c.inertia = 0.5 + rand.Float64()/2
+	case ChaoticInertia:
+		if s.chaos == 0 {
+			s.chaos = rand.Float64()
+		}
+		s.chaos = 4 * s.chaos * (1 - s.chaos)
+		c.inertia = (o.Inertia-o.InertiaMin)*(1-progress) + o.InertiaMin*s.chaos
+	}
+	s.step++
+	return c
+}
+
+// constricted reports whether constriction replaces inertia, which needs Cognitive + Social above 4
+func (o Options) constricted() bool {
+	return o.Constriction && o.Cognitive+o.Social > 4
+}
+
+// constrictionFactor is Clerc's constriction coefficient for phi = c1 + c2 > 4
+func constrictionFactor(phi float64) float64 {
+	if phi <= 4 {
+		return 1.0
+	}
+	return 2 / math.Abs(2-phi-math.Sqrt(phi*phi-4*phi))
+}
@@ -2,52 +2,81 @@ package pso
 
 import (
 	"log"
+	"math"
 	"math/rand"
 
 	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
 )
 
-//movement weightings
-const (
-	inertia    = 0.721 // weighting of momentum maintained between steps
-	cognitiveW = 1.2   // (default) 1.1193 // weighting towards personal best position
-	socialW    = 1.1   // (default) 1.1193 // weighting towards global best position
-)
-
 // Particle is a struct representing a single particle entity
 // Used to find optimal market pricing
 type Particle struct {
 	prices, velocity, bestPrices []float64
+	neighbourhoodBest            []float64 // best prices found by the particles informing this particle
 	currentRevenue, bestRevenue  float64
+	exemplars                    []int // particle each price learns from, CLPSO only
+	stale                        int   // steps since the personal best improved, CLPSO only
+}
+
+// VelocityStat summarises the magnitude of every particle's velocity after one step
+type VelocityStat struct {
+	Mean, Max float64
 }
 
 // Swarm models a population of Particles along with the current best prices and revenue
 type Swarm struct {
-	Particles   []*Particle
-	BestPrices  []float64
-	BestRevenue float64
-	numGoods    int
-	problem     *pp.PricingProblem
+	Particles     []*Particle
+	BestPrices    []float64
+	BestRevenue   float64
+	Velocity      VelocityStat   // velocity magnitudes after the latest step
+	PeakVelocity  float64        // largest velocity magnitude of any particle since Init
+	VelocityStats []VelocityStat // velocity magnitudes of each step since Init, only kept with Options.RecordVelocity
+	numGoods      int
+	numParticles  int
+	problem       pp.Problem
+	boundary      *boundaryHandler
+	opts          Options
+	schedule      schedule
+	neighbours    [][]int // indices of the particles informing each particle, nil when global
+	stagnant      int     // steps since BestRevenue last improved
 }
 
-// NewSwarm generates a new population of Particles
-func NewSwarm(numGoods int, numParticles int, pr *pp.PricingProblem) *Swarm {
-	// define and populate new swarm
+// NewSwarm generates a new population of Particles, moving according to opts
+// if pr is nil, the swarm is left empty until Init is called
+func NewSwarm(numGoods int, numParticles int, opts Options, pr pp.Problem) *Swarm {
+	// define new swarm
 	sw := new(Swarm)
-	sw.problem = pr
 	sw.numGoods = numGoods
-	sw.Particles = make([]*Particle, numParticles)
+	sw.numParticles = numParticles
+	sw.opts = opts
+	sw.boundary = newBoundaryHandler(numGoods, opts.Boundary, opts.Velocity)
+	if pr != nil {
+		sw.Init(pr)
+	}
+	return sw
+}
+
+// Init populates the swarm with new random Particles for problem pr, discarding any previous progress
+func (sw *Swarm) Init(pr pp.Problem) {
+	sw.problem = pr
+	sw.numGoods = len(pr.Bounds())
+	sw.boundary = newBoundaryHandler(sw.numGoods, sw.opts.Boundary, sw.opts.Velocity)
+	sw.schedule = schedule{}
+	sw.Particles = make([]*Particle, sw.numParticles)
+	sw.BestPrices, sw.BestRevenue = nil, 0
+	sw.neighbours = neighbourhoods(sw.opts, sw.numParticles)
+	sw.stagnant = 0
+	sw.Velocity, sw.PeakVelocity, sw.VelocityStats = VelocityStat{}, 0, nil
 
 	// create the population of particles
-	for i := 0; i < numParticles; i++ {
-		sw.Particles[i] = sw.NewParticle(numGoods)
-		if sw.Particles[i].currentRevenue > sw.BestRevenue {
+	for i := 0; i < sw.numParticles; i++ {
+		sw.Particles[i] = sw.NewParticle(sw.numGoods)
+		if sw.BestPrices == nil || sw.Particles[i].currentRevenue > sw.BestRevenue {
 			// assign values to best prices and revenue
-			sw.BestPrices = sw.Particles[i].prices
+			sw.BestPrices = append([]float64{}, sw.Particles[i].prices...) //important to copy due to pass by reference
 			sw.BestRevenue = sw.Particles[i].currentRevenue
 		}
 	}
-	return sw
 }
 
 // NewParticle generates and returns a new instanc of a particle
@@ -59,28 +88,140 @@ func (sw *Swarm) NewParticle(numGoods int) *Particle {
 	p.velocity = initialVelocity(p.prices, randomPrices(numGoods, sw.problem))
 	p.bestPrices = make([]float64, len(p.prices))
 	copy(p.bestPrices, p.prices) //important to copy due to pass by reference
+	p.neighbourhoodBest = make([]float64, len(p.prices))
 	p.currentRevenue = evaluatePrices(p.prices, sw.problem)
 	p.bestRevenue = p.currentRevenue
 	return p
 }
 
-// Update (Swarm) iterates over the population of particles to continue the progress of the swarm by one step
-func (sw *Swarm) Update() {
+// Options returns the parameters the swarm moves with
+func (sw *Swarm) Options() Options {
+	return sw.opts
+}
+
+// Params lists the parameters the swarm moves with, for recording alongside results
+func (sw *Swarm) Params() map[string]string {
+	return sw.opts.Params()
+}
+
+// BoundaryHits returns the number of times each bound has been crossed since the swarm was initialised
+func (sw *Swarm) BoundaryHits() BoundaryHits {
+	return sw.boundary.hits
+}
+
+// Best returns a copy of the best prices found by the swarm, and their revenue
+func (sw *Swarm) Best() ([]float64, float64) {
+	return append([]float64{}, sw.BestPrices...), sw.BestRevenue
+}
+
+// Inject replaces the worst particle with one at prices with the given revenue, at rest
+// used to feed prices found by another search, such as a local search, back into the swarm
+func (sw *Swarm) Inject(prices []float64, revenue float64) {
+	worst := 0
+	for i, p := range sw.Particles {
+		if p.currentRevenue < sw.Particles[worst].currentRevenue {
+			worst = i
+		}
+	}
+	p := sw.Particles[worst]
+	copy(p.prices, prices)
+	copy(p.bestPrices, prices)
+	p.currentRevenue, p.bestRevenue = revenue, revenue
+	for j := range p.velocity {
+		p.velocity[j] = 0
+	}
+	if revenue > sw.BestRevenue {
+		copy(sw.BestPrices, prices)
+		sw.BestRevenue = revenue
+		sw.stagnant = 0
+	}
+}
+
+// Step (Swarm) iterates over the population of particles to continue the progress of the swarm by one step
+// each particle moves towards the best position found within its neighbourhood topology
+func (sw *Swarm) Step() {
+	c := sw.schedule.next(sw.opts, sw.problem.Bounds())
+	improved := false
+	stat := VelocityStat{}
 	for i := 0; i < len(sw.Particles); i++ {
-		sw.Particles[i].Update(sw.numGoods, sw.BestPrices, sw.problem)
-		if sw.Particles[i].currentRevenue > sw.BestRevenue {
+		p := sw.Particles[i]
+		copy(p.neighbourhoodBest, sw.neighbourhoodBest(i)) //important to copy due to pass by reference
+		switch sw.opts.Variant {
+		case Canonical:
+			p.Update(sw.numGoods, p.neighbourhoodBest, sw.problem, sw.boundary, c)
+		case BareBones:
+			copy(p.velocity, bareBonesVelocity(p.prices, p.bestPrices, p.neighbourhoodBest, sw.problem.Bounds()))
+			p.move(sw.problem, sw.boundary)
+		case FIPS:
+			copy(p.velocity, fipsVelocity(p.velocity, p.prices, sw.informants(i), c))
+			p.move(sw.problem, sw.boundary)
+		case CLPSO:
+			sw.clpsoUpdate(i, c)
+		}
+		if p.currentRevenue > sw.BestRevenue {
 			// ensures the best result is updated as necessary
-			sw.BestPrices = sw.Particles[i].prices
-			sw.BestRevenue = sw.Particles[i].currentRevenue
+			copy(sw.BestPrices, p.prices) //important to copy due to pass by reference
+			sw.BestRevenue = p.currentRevenue
+			improved = true
 		}
+		magnitude := p.speed()
+		stat.Mean += magnitude / float64(len(sw.Particles))
+		stat.Max = math.Max(stat.Max, magnitude)
+	}
+	sw.Velocity, sw.PeakVelocity = stat, math.Max(sw.PeakVelocity, stat.Max)
+	if sw.opts.RecordVelocity {
+		sw.VelocityStats = append(sw.VelocityStats, stat)
+	}
+
+	// dynamic neighbourhoods are redrawn when the swarm stagnates
+	sw.stagnant++
+	if improved {
+		sw.stagnant = 0
+	}
+	if sw.opts.Topology == DynamicTopology && sw.stagnant >= sw.opts.Patience {
+		sw.neighbours = neighbourhoods(sw.opts, len(sw.Particles))
+		sw.stagnant = 0
 	}
 }
 
+// informants returns the particles informing particle i, every particle for GlobalTopology
+func (sw *Swarm) informants(i int) []*Particle {
+	if sw.neighbours == nil {
+		return sw.Particles
+	}
+	informants := make([]*Particle, len(sw.neighbours[i]))
+	for k, j := range sw.neighbours[i] {
+		informants[k] = sw.Particles[j]
+	}
+	return informants
+}
+
+// neighbourhoodBest returns the best prices found by the particles informing particle i
+func (sw *Swarm) neighbourhoodBest(i int) []float64 {
+	if sw.neighbours == nil {
+		return sw.BestPrices
+	}
+	best := sw.Particles[i]
+	for _, j := range sw.neighbours[i] {
+		if sw.Particles[j].bestRevenue > best.bestRevenue {
+			best = sw.Particles[j]
+		}
+	}
+	return best.bestPrices
+}
+
 // Update (Particle) handles the repositioning and evaluation of a particle
-// param: gBestPrices passes information of the global best prices across a whole population of particles
-func (p *Particle) Update(numGoods int, gBestPrices []float64, pr *pp.PricingProblem) {
-	copy(p.velocity, calculateVelocity(p.velocity, p.prices, p.bestPrices, gBestPrices)) //important to copy due to pass by reference
-	copy(p.prices, updatePosition(p.prices, p.velocity, pr))                             //important to copy due to pass by reference
+// param: gBestPrices passes information of the best prices in the particle's neighbourhood (the whole swarm, for GlobalTopology)
+// param: bh repairs the particle if it leaves the problem bounds
+// param: c weights the movement towards each best position
+func (p *Particle) Update(numGoods int, gBestPrices []float64, pr pp.Problem, bh *boundaryHandler, c coefficients) {
+	copy(p.velocity, calculateVelocity(p.velocity, p.prices, p.bestPrices, gBestPrices, c)) //important to copy due to pass by reference
+	p.move(pr, bh)
+}
+
+// move repositions the particle by its velocity, then evaluates it and updates its personal best
+func (p *Particle) move(pr pp.Problem, bh *boundaryHandler) {
+	copy(p.prices, updatePosition(p.prices, p.velocity, pr, bh)) //important to copy due to pass by reference
 	p.currentRevenue = evaluatePrices(p.prices, pr)
 	if p.currentRevenue > p.bestRevenue {
 		copy(p.bestPrices, p.prices) //important to copy due to pass by reference
@@ -88,19 +229,39 @@ func (p *Particle) Update(numGoods int, gBestPrices []float64, pr *pp.PricingPro
 	}
 }
 
+// speed returns the magnitude (euclidean length) of the particle's velocity
+func (p *Particle) speed() float64 {
+	var sum float64
+	for _, v := range p.velocity {
+		sum += v * v
+	}
+	return math.Sqrt(sum)
+}
+
 // calculateVelocity calculates the movement properties ready for updating a Particle's position
-// uses inertia, cognitiveW, socialW constants
-func calculateVelocity(velocity, prices, pBestPrices, gBestPrices []float64) []float64 {
+// uses the inertia, cognitive, social and constriction coefficients of the current step
+func calculateVelocity(velocity, prices, pBestPrices, gBestPrices []float64, c coefficients) []float64 {
 	newVelocity := make([]float64, len(velocity))
 	for i := 0; i < len(velocity); i++ {
 		r1, r2 := rand.Float64(), rand.Float64()
-		newVelocity[i] = (inertia * velocity[i]) + (cognitiveW * r1 * (pBestPrices[i] - prices[i])) + (socialW * r2 * (gBestPrices[i] - prices[i]))
+		newVelocity[i] = c.constriction * ((c.inertia * velocity[i]) + (c.cognitive * r1 * (pBestPrices[i] - prices[i])) + (c.social * r2 * (gBestPrices[i] - prices[i])))
 	}
-	return newVelocity
+	return clampVelocity(newVelocity, c)
+}
+
+// clampVelocity limits each dimension of velocity to +/- c.vmax, when clamping is enabled
+func clampVelocity(velocity []float64, c coefficients) []float64 {
+	if c.vmax == nil {
+		return velocity
+	}
+	for i := 0; i < len(velocity); i++ {
+		velocity[i] = math.Max(-c.vmax[i], math.Min(c.vmax[i], velocity[i]))
+	}
+	return velocity
 }
 
 // evaluatePrices calculates the revenue for the provided prices
-func evaluatePrices(prices []float64, pr *pp.PricingProblem) float64 {
+func evaluatePrices(prices []float64, pr pp.Problem) float64 {
 	revenue, err := pr.Evaluate(prices)
 	if err != nil {
 		log.Fatal(err)
@@ -118,22 +279,24 @@ func initialVelocity(firstPrices, secondPrices []float64) []float64 {
 	return velocity
 }
 
-// randomPrices generates random prices that evaluated as valid by the PricingProblem
-func randomPrices(numGoods int, pr *pp.PricingProblem) []float64 {
+// randomPrices generates random prices within the bounds of the problem
+func randomPrices(numGoods int, pr pp.Problem) []float64 {
 	prices := make([]float64, numGoods)
 	for !pr.IsValid(prices) {
 		for i := 0; i < numGoods; i++ {
-			prices[i] = rand.Float64() * 10
+			prices[i] = pr.Bounds()[i][0] + rand.Float64()*(pr.Bounds()[i][1]-pr.Bounds()[i][0])
 		}
 	}
 	return prices
 }
 
 // updatePosition uses the velocity to update the location of the Particle
-func updatePosition(prices, velocity []float64, pr *pp.PricingProblem) []float64 {
+// any prices moved outside the problem bounds are repaired by bh, which may also adjust velocity
+func updatePosition(prices, velocity []float64, pr pp.Problem, bh *boundaryHandler) []float64 {
 	newPrices := make([]float64, len(prices))
 	for i := 0; i < len(prices); i++ {
 		newPrices[i] = prices[i] + velocity[i]
 	}
+	bh.repair(newPrices, velocity, pr.Bounds())
 	return newPrices
 }
@@ -1,6 +1,9 @@
 package pso
 
 import (
+	"fmt"
+	"math"
+	"sort"
 	"testing"
 
 	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
@@ -9,7 +12,7 @@ import (
 func Test_NewParticle(t *testing.T) {
 	p := pp.PricingProblem{}
 	pr := *p.MakeProblem(2, 0, false)
-	sw := NewSwarm(2, 1, &pr)
+	sw := NewSwarm(2, 1, DefaultOptions(), &pr)
 	if len(sw.Particles[0].prices) != 2 {
 		t.Errorf("particle prices design length not as expected : %v", len(sw.Particles[0].prices))
 	}
@@ -31,7 +34,8 @@ func Test_calculateVelocity(t *testing.T) {
 	p1 := []float64{0.1, 0.5, 1, 3}
 	p2 := []float64{0.2, 1, 2, 6}
 	v := initialVelocity(p1, p2)
-	newV := calculateVelocity(v, p1, p1, []float64{0.5, 3.2, 2.1, 0.2})
+	c := (&schedule{}).next(DefaultOptions(), nil)
+	newV := calculateVelocity(v, p1, p1, []float64{0.5, 3.2, 2.1, 0.2}, c)
 	if newV[0] == v[0] {
 		t.Errorf("new 0 calculated velocity did not change : %v", newV[0])
 	}
@@ -68,7 +72,7 @@ func Test_updatePosition(t *testing.T) {
 	p := pp.PricingProblem{}
 	pr := *p.MakeProblem(2, 0, false)
 
-	np := updatePosition(p1, v, &pr)
+	np := updatePosition(p1, v, &pr, newBoundaryHandler(2, Clamp, ZeroVelocity))
 	if len(np) != len(p1) {
 		t.Errorf("incorrect position length : %v", len(np))
 	}
@@ -79,3 +83,341 @@ func Test_updatePosition(t *testing.T) {
 		t.Errorf("new design 1 did not update : %v", np[1])
 	}
 }
+
+func Test_boundaryRepair(t *testing.T) {
+	bounds := [][]float64{{0.01, 10.0}, {0.01, 10.0}}
+	tests := []struct {
+		strategy BoundaryStrategy
+		velocity VelocityPolicy
+		prices   []float64
+		expected []float64
+		expVel   []float64
+	}{
+		{Clamp, ZeroVelocity, []float64{-1, 12}, []float64{0.01, 10}, []float64{0, 0}},
+		{Reflect, InvertVelocity, []float64{-0.99, 12}, []float64{1.01, 8}, []float64{1, -1}},
+		{Wrap, InvertVelocity, []float64{-0.99, 12}, []float64{9, 2.01}, []float64{1, -1}},
+	}
+	for _, test := range tests {
+		bh := newBoundaryHandler(2, test.strategy, test.velocity)
+		velocity := []float64{-1, 1}
+		bh.repair(test.prices, velocity, bounds)
+		for i := 0; i < len(test.prices); i++ {
+			if math.Abs(test.prices[i]-test.expected[i]) > 1e-9 {
+				t.Errorf("%v : expected price[%v] %v, actual %v", test.strategy, i, test.expected[i], test.prices[i])
+			}
+			if velocity[i] != test.expVel[i] {
+				t.Errorf("%v : expected velocity[%v] %v, actual %v", test.velocity, i, test.expVel[i], velocity[i])
+			}
+		}
+		if bh.hits.Lower[0] != 1 || bh.hits.Upper[1] != 1 || bh.hits.Total() != 2 {
+			t.Errorf("%v : unexpected boundary hits : %v", test.strategy, bh.hits)
+		}
+	}
+
+	// a zero-width bound fixes the price, whichever strategy repairs it
+	fixed := [][]float64{{5, 5}, {5, 5}}
+	for _, strategy := range []BoundaryStrategy{Clamp, Reflect, RandomReinit, Wrap} {
+		bh := newBoundaryHandler(2, strategy, InvertVelocity)
+		prices, velocity := []float64{4, 17}, []float64{-1, 1}
+		bh.repair(prices, velocity, fixed)
+		if prices[0] != 5 || prices[1] != 5 {
+			t.Errorf("%v : expected the fixed price 5, actual %v", strategy, prices)
+		}
+	}
+
+	// random reinitialisation and damping must stay within bounds
+	bh := newBoundaryHandler(2, RandomReinit, DampVelocity)
+	prices, velocity := []float64{-5, 50}, []float64{-1, 1}
+	bh.repair(prices, velocity, bounds)
+	for i := 0; i < len(prices); i++ {
+		if prices[i] < bounds[i][0] || prices[i] > bounds[i][1] {
+			t.Errorf("random reinit price[%v] out of bounds : %v", i, prices[i])
+		}
+		if math.Abs(velocity[i]) > 1 {
+			t.Errorf("damped velocity[%v] grew : %v", i, velocity[i])
+		}
+	}
+}
+
+func Test_velocityClamping(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := *p.MakeProblem(5, 0, false)
+
+	// inertia above 1 with inverted (not zeroed) velocity at the bounds lets velocity grow without limit
+	opts := DefaultOptions()
+	opts.Inertia = 1.2
+	opts.Boundary, opts.Velocity = Reflect, InvertVelocity
+	divergent := NewSwarm(5, 10, opts, &pr)
+	for i := 0; i < 200; i++ {
+		divergent.Step()
+	}
+	if divergent.Velocity.Max < 1e6 || divergent.PeakVelocity < divergent.Velocity.Max {
+		t.Errorf("expected unclamped velocity to diverge, max : %v, peak %v", divergent.Velocity.Max, divergent.PeakVelocity)
+	}
+	if divergent.VelocityStats != nil {
+		t.Errorf("velocity of each step kept without RecordVelocity : %v", len(divergent.VelocityStats))
+	}
+
+	// the same setting is tamed by clamping each dimension to 20% of the bound range
+	opts.VMax, opts.RecordVelocity = 0.2, true
+	clamped := NewSwarm(5, 10, opts, &pr)
+	limit := math.Sqrt(5) * 0.2 * (10.0 - 0.01)
+	for i := 0; i < 200; i++ {
+		clamped.Step()
+		if stat := clamped.VelocityStats[i]; stat.Max > limit+1e-9 || stat.Mean > stat.Max || stat != clamped.Velocity {
+			t.Fatalf("step %v velocity not clamped to %v : %v", i, limit, stat)
+		}
+	}
+	if len(clamped.VelocityStats) != 200 || clamped.PeakVelocity > limit+1e-9 {
+		t.Errorf("recorded %v steps, peak %v", len(clamped.VelocityStats), clamped.PeakVelocity)
+	}
+
+	// decay shrinks the limit each step
+	opts.VMaxDecay = 0.9
+	decayed := NewSwarm(5, 10, opts, &pr)
+	for i := 0; i < 50; i++ {
+		decayed.Step()
+	}
+	if last := decayed.VelocityStats[49]; last.Max > limit*math.Pow(0.9, 49)+1e-9 {
+		t.Errorf("velocity limit did not decay : %v", last.Max)
+	}
+}
+
+func Test_constrictionBoundary(t *testing.T) {
+	tests := []struct {
+		cognitive, social float64
+		constricted       bool
+	}{
+		{2.05, 2.05, true},
+		{2.0, 2.0001, true},
+		{2.0, 2.0, false}, // phi = 4 has no damping factor
+		{1.2, 1.1, false},
+	}
+	for _, tt := range tests {
+		opts := ConstrictionOptions()
+		opts.Cognitive, opts.Social = tt.cognitive, tt.social
+		c := (&schedule{}).next(opts, nil)
+		_, reported := opts.Params()["constriction"]
+		if tt.constricted {
+			if c.inertia != 1 || c.constriction >= 1 || !reported {
+				t.Errorf("phi %v : expected constriction, actual %+v", tt.cognitive+tt.social, c)
+			}
+			continue
+		}
+		if c.inertia != opts.Inertia || c.constriction != 1 || reported {
+			t.Errorf("phi %v : expected the inertia schedule, actual %+v", tt.cognitive+tt.social, c)
+		}
+	}
+
+	// phi = 4 previously left velocities undamped, diverging with inverted velocities at the bounds
+	p := pp.PricingProblem{}
+	pr := *p.MakeProblem(5, 0, false)
+	opts := ConstrictionOptions()
+	opts.Cognitive, opts.Social = 2, 2
+	opts.Boundary, opts.Velocity = Reflect, InvertVelocity
+	sw := NewSwarm(5, 10, opts, &pr)
+	for i := 0; i < 200; i++ {
+		sw.Step()
+	}
+	if sw.Velocity.Max > 100 {
+		t.Errorf("velocity diverged with phi = 4, max : %v", sw.Velocity.Max)
+	}
+}
+
+func Test_neighbourhoods(t *testing.T) {
+	sorted := func(nb []int) []int {
+		s := append([]int{}, nb...)
+		sort.Ints(s)
+		return s
+	}
+	opts := DefaultOptions()
+	if nb := neighbourhoods(opts, 9); nb != nil {
+		t.Errorf("global topology should have no neighbourhoods : %v", nb)
+	}
+
+	tests := []struct {
+		topology Topology
+		radius   int
+		expected map[int][]int // neighbourhoods of some of 9 particles
+	}{
+		{RingTopology, 1, map[int][]int{0: {0, 1, 8}, 4: {3, 4, 5}, 8: {0, 7, 8}}},
+		{RingTopology, 2, map[int][]int{0: {0, 1, 2, 7, 8}}},
+		{RingTopology, 10, map[int][]int{3: {0, 1, 2, 3, 4, 5, 6, 7, 8}}},
+		// a 3 by 3 grid, wrapping at the edges
+		// particles at the end of a row wrap to the start of the same row
+		{VonNeumannTopology, 0, map[int][]int{0: {0, 1, 2, 3, 6}, 3: {0, 3, 4, 5, 6}, 4: {1, 3, 4, 5, 7}, 5: {2, 3, 4, 5, 8}, 8: {2, 5, 6, 7, 8}}},
+		{WheelTopology, 0, map[int][]int{0: {0, 1, 2, 3, 4, 5, 6, 7, 8}, 1: {0, 1}, 8: {0, 8}}},
+	}
+	for _, tt := range tests {
+		opts.Topology, opts.Radius = tt.topology, tt.radius
+		nb := neighbourhoods(opts, 9)
+		for i, expected := range tt.expected {
+			if actual := sorted(nb[i]); fmt.Sprint(actual) != fmt.Sprint(expected) {
+				t.Errorf("%v radius %v : particle %v expected neighbours %v, actual %v", tt.topology, tt.radius, i, expected, actual)
+			}
+		}
+	}
+
+	// 7 particles leave a short last row, holding particle 6 alone, so columns 1 and 2 have only two rows
+	opts.Topology = VonNeumannTopology
+	nb := neighbourhoods(opts, 7)
+	for i, expected := range map[int][]int{0: {0, 1, 2, 3, 6}, 2: {0, 1, 2, 5}, 4: {1, 3, 4, 5}, 6: {0, 3, 6}} {
+		if actual := sorted(nb[i]); fmt.Sprint(actual) != fmt.Sprint(expected) {
+			t.Errorf("von neumann of 7 : particle %v expected neighbours %v, actual %v", i, expected, actual)
+		}
+	}
+
+	opts.Topology, opts.NeighbourhoodSize = DynamicTopology, 3
+	for i, nb := range neighbourhoods(opts, 9) {
+		s := sorted(nb)
+		if nb[0] != i || len(nb) < 2 || len(nb) > 4 {
+			t.Errorf("dynamic neighbourhood of %v should hold itself and up to 3 others : %v", i, nb)
+		}
+		for k := 1; k < len(s); k++ {
+			if s[k] == s[k-1] || s[k] < 0 || s[k] >= 9 {
+				t.Errorf("dynamic neighbourhood of %v has repeated or invalid particles : %v", i, nb)
+			}
+		}
+	}
+}
+
+func Test_neighbourhoodBest(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := *p.MakeProblem(2, 0, false)
+	opts := DefaultOptions()
+	opts.Topology = RingTopology
+	sw := NewSwarm(2, 5, opts, &pr)
+	// particle k has the kth best revenue, at prices (k, k)
+	for k, particle := range sw.Particles {
+		particle.bestRevenue = float64(k)
+		particle.bestPrices = []float64{float64(k), float64(k)}
+	}
+	for i, expected := range []float64{4, 2, 3, 4, 4} {
+		if best := sw.neighbourhoodBest(i); best[0] != expected {
+			t.Errorf("particle %v expected the best of particle %v, actual %v", i, expected, best)
+		}
+	}
+
+	// every particle is informed by the swarm's best under the global topology
+	sw.opts.Topology = GlobalTopology
+	sw.neighbours = neighbourhoods(sw.opts, 5)
+	for i := range sw.Particles {
+		if best := sw.neighbourhoodBest(i); fmt.Sprint(best) != fmt.Sprint(sw.BestPrices) {
+			t.Errorf("particle %v expected the swarm's best %v, actual %v", i, sw.BestPrices, best)
+		}
+	}
+}
+
+func Test_bareBonesSampling(t *testing.T) {
+	// positions are drawn from N(3, 2), unaffected by the step's velocity limit
+	bounds := [][]float64{{-100, 100}}
+	var sum, sumSq float64
+	n := 20000
+	for k := 0; k < n; k++ {
+		x := 1 + bareBonesVelocity([]float64{1}, []float64{2}, []float64{4}, bounds)[0]
+		sum += x
+		sumSq += x * x
+	}
+	mean := sum / float64(n)
+	sd := math.Sqrt(sumSq/float64(n) - mean*mean)
+	if math.Abs(mean-3) > 0.1 || math.Abs(sd-2) > 0.1 {
+		t.Errorf("expected samples from N(3, 2), actual mean %v, sd %v", mean, sd)
+	}
+
+	// samples beyond the bounds are clamped to them
+	bounds = [][]float64{{2.5, 3.5}}
+	for k := 0; k < 1000; k++ {
+		if x := 1 + bareBonesVelocity([]float64{1}, []float64{2}, []float64{4}, bounds)[0]; x < 2.5 || x > 3.5 {
+			t.Fatalf("sample %v outside bounds %v", x, bounds[0])
+		}
+	}
+}
+
+func Test_fipsVelocity(t *testing.T) {
+	opts := ConstrictionOptions()
+	c := (&schedule{}).next(opts, nil)
+	informants := []*Particle{{bestPrices: []float64{1}}, {bestPrices: []float64{1}}, {bestPrices: []float64{1}}}
+
+	// informants at the particle's position leave only the constricted previous velocity
+	if v := fipsVelocity([]float64{2}, []float64{1}, informants, c); math.Abs(v[0]-2*c.constriction) > 1e-12 {
+		t.Errorf("expected velocity %v, actual %v", 2*c.constriction, v[0])
+	}
+	// otherwise each informant pulls by U(0, phi / 3), phi / 2 on average in total
+	var sum float64
+	n := 20000
+	for k := 0; k < n; k++ {
+		sum += fipsVelocity([]float64{0}, []float64{0}, informants, c)[0]
+	}
+	if expected := c.constriction * 4.1 / 2; math.Abs(sum/float64(n)-expected) > 0.02 {
+		t.Errorf("expected mean velocity %v, actual %v", expected, sum/float64(n))
+	}
+}
+
+func Test_clpsoExemplars(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := *p.MakeProblem(10, 0, false)
+	opts := DefaultOptions()
+	opts.Variant = CLPSO
+	sw := NewSwarm(10, 10, opts, &pr)
+
+	learned := make([]int, 10)
+	for k := 0; k < 200; k++ {
+		for i := range sw.Particles {
+			others := 0
+			for _, e := range sw.chooseExemplars(i) {
+				if e < 0 || e >= 10 {
+					t.Fatalf("invalid exemplar %v", e)
+				}
+				if e != i {
+					others++
+				}
+			}
+			if others == 0 {
+				t.Fatalf("particle %v learns only from itself", i)
+			}
+			learned[i] += others
+		}
+	}
+	// the learning probability rises from 0.05 for the first particle to 0.5 for the last
+	if learned[0] >= learned[9] || float64(learned[9])/2000 < 0.4 {
+		t.Errorf("expected the last particle to learn from others more often : %v", learned)
+	}
+
+	// exemplars are kept until the particle has not improved for RefreshGap steps
+	particle := sw.Particles[3]
+	particle.exemplars, particle.stale = []int{7, 7, 7, 7, 7, 7, 7, 7, 7, 7}, 0
+	c := sw.schedule.next(sw.opts, pr.Bounds())
+	particle.bestRevenue = math.Inf(1) // never improves
+	for k := 0; k < opts.RefreshGap; k++ {
+		sw.clpsoUpdate(3, c)
+	}
+	if particle.stale != opts.RefreshGap || particle.exemplars[0] != 7 {
+		t.Errorf("exemplars changed before the refresh gap : %v, stale %v", particle.exemplars, particle.stale)
+	}
+	sw.clpsoUpdate(3, c)
+	if particle.stale != 1 {
+		t.Errorf("exemplars not refreshed after the refresh gap, stale %v", particle.stale)
+	}
+}
+
+func Test_variantsImprove(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := *p.MakeProblem(10, 0, false)
+	for _, v := range Variants {
+		opts := ConstrictionOptions()
+		opts.Variant = v
+		sw := NewSwarm(10, 20, opts, &pr)
+		_, initial := sw.Best()
+		for i := 0; i < 100; i++ {
+			sw.Step()
+			for _, particle := range sw.Particles {
+				if !pr.IsValid(particle.prices) {
+					t.Fatalf("%v : particle left the bounds : %v", v, particle.prices)
+				}
+			}
+		}
+		if _, rev := sw.Best(); rev <= initial {
+			t.Errorf("%v : revenue %v did not improve on %v", v, rev, initial)
+		}
+	}
+}
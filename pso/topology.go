@@ -0,0 +1,120 @@
+package pso
+
+import (
+	"math"
+	"math/rand"
+)
+
+// Topology determines which particles inform each other of their best positions
+type Topology int
+
+const (
+	// GlobalTopology informs every particle of the best position found by the whole swarm
+	GlobalTopology Topology = iota
+	// RingTopology informs each particle of the particles within Radius places either side of it
+	RingTopology
+	// VonNeumannTopology places particles on a grid, informed by the particles above, below, left and right,
+	// each row and column wrapping around on itself
+	VonNeumannTopology
+	// DynamicTopology informs each particle of NeighbourhoodSize random particles,
+	// redrawn whenever the swarm's best revenue has not improved for Patience steps
+	DynamicTopology
+	// WheelTopology informs the first (hub) particle of every particle, and every other particle of only the hub
+	WheelTopology
+)
+
+// Topologies lists every neighbourhood topology, for comparisons
+var Topologies = []Topology{GlobalTopology, RingTopology, VonNeumannTopology, DynamicTopology, WheelTopology}
+
+func (t Topology) String() string {
+	switch t {
+	case GlobalTopology:
+		return "global"
+	case RingTopology:
+		return "ring"
+	case VonNeumannTopology:
+		return "von neumann"
+	case DynamicTopology:
+		return "dynamic"
+	case WheelTopology:
+		return "wheel"
+	}
+	return "unknown"
+}
+
+// neighbourhoods builds the indices of the particles informing each of n particles, always including itself
+// returns nil for GlobalTopology, as every particle is informed by the swarm's best
+func neighbourhoods(o Options, n int) [][]int {
+	if o.Topology == GlobalTopology {
+		return nil
+	}
+	nb := make([][]int, n)
+	for i := 0; i < n; i++ {
+		nb[i] = []int{i}
+		switch o.Topology {
+		case RingTopology:
+			radius := o.Radius
+			if radius < 1 {
+				radius = 1
+			}
+			for r := 1; r <= radius && r < n; r++ {
+				nb[i] = appendUnique(nb[i], (i+r)%n, (i-r+n)%n)
+			}
+		case VonNeumannTopology:
+			nb[i] = appendUnique(nb[i], gridNeighbours(i, n)...)
+		case DynamicTopology:
+			for k := 0; k < o.NeighbourhoodSize; k++ {
+				nb[i] = appendUnique(nb[i], rand.Intn(n))
+			}
+		case WheelTopology:
+			if i == 0 {
+				for j := 1; j < n; j++ {
+					nb[i] = append(nb[i], j)
+				}
+			} else {
+				nb[i] = append(nb[i], 0)
+			}
+		}
+	}
+	return nb
+}
+
+// gridNeighbours returns the particles left, right, above and below particle i, on a grid of n particles
+// filled row by row, as square as possible, each row and column wrapping around on itself
+// when n is not a square the last row is short, and the columns beyond it have one fewer row
+func gridNeighbours(i, n int) []int {
+	cols := int(math.Ceil(math.Sqrt(float64(n))))
+	rows := (n + cols - 1) / cols
+	row, col := i/cols, i%cols
+	rowLen := cols
+	if row == rows-1 {
+		rowLen = n - row*cols
+	}
+	colLen := rows
+	if col >= n-(rows-1)*cols {
+		colLen = rows - 1
+	}
+	return []int{
+		row*cols + (col+1)%rowLen,
+		row*cols + (col-1+rowLen)%rowLen,
+		((row+1)%colLen)*cols + col,
+		((row-1+colLen)%colLen)*cols + col,
+	}
+}
+
+// appendUnique appends each index that is not already in the neighbourhood
+func appendUnique(nb []int, indices ...int) []int {
+	for _, index := range indices {
+		found := false
+		for _, existing := range nb {
+			if existing == index {
+				found = true
+				break
+			}
+		}
+		if !found {
+			nb = append(nb, index)
+		}
+	}
+	return nb
+}
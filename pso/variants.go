@@ -0,0 +1,104 @@
+package pso
+
+import (
+	"math"
+	"math/rand"
+)
+
+// bareBonesVelocity samples a new position for each price from a Gaussian
+// centred between the personal and neighbourhood best, with a deviation of their distance apart
+// samples outside the bounds are clamped to them, rather than the movement being clamped, so the sample is not distorted
+// returns the movement to the new position, so it can be recorded like a velocity
+func bareBonesVelocity(prices, pBestPrices, nBestPrices []float64, bounds [][]float64) []float64 {
+	newVelocity := make([]float64, len(prices))
+	for i := 0; i < len(prices); i++ {
+		mean := (pBestPrices[i] + nBestPrices[i]) / 2
+		sd := math.Abs(pBestPrices[i] - nBestPrices[i])
+		position := math.Min(math.Max(mean+rand.NormFloat64()*sd, bounds[i][0]), bounds[i][1])
+		newVelocity[i] = position - prices[i]
+	}
+	return newVelocity
+}
+
+// fipsVelocity moves towards the best position of every informant, each weighted by an equal share of
+// the total acceleration phi = cognitive + social, rather than only the personal and neighbourhood best
+// this is Mendes' constricted FIPS when Constriction is set, as by ConstrictionOptions (phi = 4.1, factor ~0.7298),
+// otherwise the previous velocity is weighted by the inertia of the step and there is no constriction
+func fipsVelocity(velocity, prices []float64, informants []*Particle, c coefficients) []float64 {
+	phi := (c.cognitive + c.social) / float64(len(informants))
+	newVelocity := make([]float64, len(velocity))
+	for i := 0; i < len(velocity); i++ {
+		var pull float64
+		for _, informant := range informants {
+			pull += rand.Float64() * phi * (informant.bestPrices[i] - prices[i])
+		}
+		newVelocity[i] = c.constriction * ((c.inertia * velocity[i]) + pull)
+	}
+	return clampVelocity(newVelocity, c)
+}
+
+// clpsoUpdate moves particle i using comprehensive learning
+// each price moves towards the personal best of its exemplar particle, weighted by cognitive
+// exemplars are chosen again once the particle has not improved for RefreshGap steps
+func (sw *Swarm) clpsoUpdate(i int, c coefficients) {
+	p := sw.Particles[i]
+	if p.exemplars == nil || p.stale >= sw.opts.RefreshGap {
+		p.exemplars = sw.chooseExemplars(i)
+		p.stale = 0
+	}
+
+	for d := 0; d < len(p.velocity); d++ {
+		exemplar := sw.Particles[p.exemplars[d]]
+		p.velocity[d] = c.constriction * ((c.inertia * p.velocity[d]) + (c.cognitive * rand.Float64() * (exemplar.bestPrices[d] - p.prices[d])))
+	}
+	clampVelocity(p.velocity, c)
+
+	previousBest := p.bestRevenue
+	p.move(sw.problem, sw.boundary)
+	p.stale++
+	if p.bestRevenue > previousBest {
+		p.stale = 0
+	}
+}
+
+// chooseExemplars picks the particle each price of particle i learns from
+// with learning probability pc, a price learns from the better of two random other particles, else itself
+// pc ranges from 0.05 for the first particle to 0.5 for the last, so particles differ in how much they explore
+func (sw *Swarm) chooseExemplars(i int) []int {
+	n := len(sw.Particles)
+	pc := 0.05
+	if n > 1 {
+		pc += 0.45 * (math.Exp(10*float64(i)/float64(n-1)) - 1) / (math.Exp(10) - 1)
+	}
+
+	exemplars := make([]int, sw.numGoods)
+	learns := false
+	for d := 0; d < sw.numGoods; d++ {
+		exemplars[d] = i
+		if n > 1 && rand.Float64() < pc {
+			exemplars[d] = sw.tournament(i)
+			learns = true
+		}
+	}
+	// ensure at least one price learns from another particle
+	if !learns && n > 1 {
+		exemplars[rand.Intn(sw.numGoods)] = sw.tournament(i)
+	}
+	return exemplars
+}
+
+// tournament returns the better of two random particles other than particle i
+func (sw *Swarm) tournament(i int) int {
+	n := len(sw.Particles)
+	a, b := rand.Intn(n-1), rand.Intn(n-1)
+	if a >= i {
+		a++
+	}
+	if b >= i {
+		b++
+	}
+	if sw.Particles[b].bestRevenue > sw.Particles[a].bestRevenue {
+		return b
+	}
+	return a
+}
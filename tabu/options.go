@@ -0,0 +1,70 @@
+package tabu
+
+import "strconv"
+
+// Attribute determines what a move makes tabu
+type Attribute int
+
+const (
+	// GoodTabu forbids changing the price of a good again for Tenure steps
+	GoodTabu Attribute = iota
+	// DirectionTabu forbids reversing a move, moving a good's price back the way it came, for Tenure steps
+	DirectionTabu
+)
+
+// Attributes lists every tabu attribute, for comparisons
+var Attributes = []Attribute{GoodTabu, DirectionTabu}
+
+func (a Attribute) String() string {
+	switch a {
+	case GoodTabu:
+		return "good"
+	case DirectionTabu:
+		return "direction"
+	}
+	return "unknown"
+}
+
+// Options configures a Search
+type Options struct {
+	// Ladders lists the prices each good may take, sorted and clamped to the bounds when the search starts,
+	// goods without a ladder, or with an empty one, space Levels prices evenly across their bounds
+	Ladders    [][]float64
+	Levels     int
+	Stride     int // moves change a price by each power of Stride levels (1, Stride, Stride^2...), below 2 by one level only
+	Attribute  Attribute
+	Tenure     int  // steps a move stays tabu
+	Aspiration bool // allow tabu moves which beat the best revenue found
+	// Patience is the steps without improving the best revenue before diversifying
+	// to the least visited price of each good, 0 never diversifies
+	Patience int
+}
+
+// DefaultOptions returns tabu search parameters suited to the pricing problem, on a 101 level grid
+func DefaultOptions() Options {
+	return Options{
+		Levels:     101,
+		Stride:     10,
+		Attribute:  DirectionTabu,
+		Tenure:     7,
+		Aspiration: true,
+		Patience:   300,
+	}
+}
+
+// Params lists the chosen parameters, for recording alongside results
+func (o Options) Params() map[string]string {
+	params := map[string]string{
+		"stride":     strconv.Itoa(o.Stride),
+		"attribute":  o.Attribute.String(),
+		"tenure":     strconv.Itoa(o.Tenure),
+		"aspiration": strconv.FormatBool(o.Aspiration),
+		"patience":   strconv.Itoa(o.Patience),
+	}
+	if o.Ladders == nil {
+		params["levels"] = strconv.Itoa(o.Levels)
+	} else {
+		params["ladders"] = "custom"
+	}
+	return params
+}
@@ -0,0 +1,245 @@
+package tabu
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+// move changes the price of one good by a number of levels of its ladder
+type move struct {
+	good, levels int
+}
+
+// direction is 0 for moves down the ladder and 1 for moves up
+func (m move) direction() int {
+	if m.levels > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Search is a tabu search, moving each step to the best neighbouring prices on the ladders which are not tabu,
+// even when they are worse, so the search can walk out of local optima
+type Search struct {
+	Levels           []int // current level of each good on its ladder
+	Revenue          float64
+	BestPrices       []float64
+	BestRevenue      float64
+	Aspirations      int // tabu moves taken because they beat the best revenue
+	Diversifications int // restarts from the least visited levels
+	ladders          [][]float64
+	tabu             [][2]int // step until which moving each good down (0) or up (1) is tabu
+	frequency        [][]int  // steps each good has spent at each level, the long term memory
+	steps, stagnant  int
+	opts             Options
+	problem          pp.Problem
+}
+
+// NewSearch creates a tabu search, moving according to opts
+// if pr is nil, the search is left empty until Init is called
+func NewSearch(opts Options, pr pp.Problem) *Search {
+	ts := new(Search)
+	ts.opts = opts
+	if pr != nil {
+		ts.Init(pr)
+	}
+	return ts
+}
+
+// Init starts the search from random levels for problem pr, discarding any previous progress and memory
+func (ts *Search) Init(pr pp.Problem) {
+	ts.problem = pr
+	ts.ladders = ladders(ts.opts, pr.Bounds())
+	n := len(ts.ladders)
+	ts.tabu = make([][2]int, n)
+	ts.frequency = make([][]int, n)
+	ts.Levels = make([]int, n)
+	for i := range ts.ladders {
+		ts.frequency[i] = make([]int, len(ts.ladders[i]))
+		ts.Levels[i] = rand.Intn(len(ts.ladders[i]))
+	}
+	ts.steps, ts.stagnant = 0, 0
+	ts.Aspirations, ts.Diversifications = 0, 0
+	ts.BestPrices, ts.BestRevenue = nil, math.Inf(-1)
+	ts.Revenue = ts.evaluate(ts.Levels)
+	ts.keepBest()
+}
+
+// Options returns the parameters of the search
+func (ts *Search) Options() Options {
+	return ts.opts
+}
+
+// Params lists the parameters of the search, for recording alongside results
+func (ts *Search) Params() map[string]string {
+	return ts.opts.Params()
+}
+
+// Best returns a copy of the best prices found, and their revenue
+func (ts *Search) Best() ([]float64, float64) {
+	return append([]float64{}, ts.BestPrices...), ts.BestRevenue
+}
+
+// Prices returns the prices of levels on the ladders
+func (ts *Search) Prices(levels []int) []float64 {
+	prices := make([]float64, len(levels))
+	for i, l := range levels {
+		prices[i] = ts.ladders[i][l]
+	}
+	return prices
+}
+
+// Step evaluates every move of each good by a power of Stride levels, and takes the best which is not tabu,
+// or is tabu but beats the best revenue when Aspiration is set
+// diversifies once the best revenue has not improved for Patience steps
+func (ts *Search) Step() {
+	ts.steps++
+	for i, l := range ts.Levels {
+		ts.frequency[i][l]++
+	}
+
+	chosen, chosenRevenue, aspirated := move{}, math.Inf(-1), false
+	moves := ts.moves()
+	rand.Shuffle(len(moves), func(i, j int) { moves[i], moves[j] = moves[j], moves[i] }) // break ties at random
+	for _, m := range moves {
+		levels := append([]int{}, ts.Levels...)
+		levels[m.good] += m.levels
+		rev := ts.evaluate(levels)
+		tabu := ts.isTabu(m)
+		if tabu && !(ts.opts.Aspiration && rev > ts.BestRevenue) {
+			continue
+		}
+		if rev > chosenRevenue {
+			chosen, chosenRevenue, aspirated = m, rev, tabu
+		}
+	}
+
+	if !math.IsInf(chosenRevenue, -1) { // otherwise every move is tabu, so wait for one to be released
+		ts.Levels[chosen.good] += chosen.levels
+		ts.Revenue = chosenRevenue
+		ts.makeTabu(chosen)
+		if aspirated {
+			ts.Aspirations++
+		}
+	}
+
+	ts.stagnant++
+	if ts.keepBest() {
+		ts.stagnant = 0
+	}
+	if ts.opts.Patience > 0 && ts.stagnant >= ts.opts.Patience {
+		ts.diversify()
+	}
+}
+
+// moves lists the moves of each power of Stride levels (1, Stride, Stride^2...), up and down, which stay on each ladder
+func (ts *Search) moves() []move {
+	moves := []move{}
+	for i, l := range ts.Levels {
+		for s := 1; s < len(ts.ladders[i]); s *= ts.opts.Stride {
+			if l+s < len(ts.ladders[i]) {
+				moves = append(moves, move{i, s})
+			}
+			if l-s >= 0 {
+				moves = append(moves, move{i, -s})
+			}
+			if ts.opts.Stride < 2 {
+				break // only moves of one level
+			}
+		}
+	}
+	return moves
+}
+
+// isTabu checks whether m is forbidden by a recent move
+func (ts *Search) isTabu(m move) bool {
+	return ts.tabu[m.good][m.direction()] > ts.steps
+}
+
+// makeTabu records the attribute of m, forbidding the moves it determines for Tenure steps
+func (ts *Search) makeTabu(m move) {
+	until := ts.steps + ts.opts.Tenure
+	if ts.opts.Attribute == GoodTabu {
+		ts.tabu[m.good] = [2]int{until, until}
+		return
+	}
+	ts.tabu[m.good][1-m.direction()] = until // the reverse of m
+}
+
+// diversify moves each good to the level it has visited least, breaking ties at random, and clears the tabu list
+// the frequency memory is kept, so successive diversifications explore different regions
+func (ts *Search) diversify() {
+	for i := range ts.Levels {
+		least := []int{}
+		for l, f := range ts.frequency[i] {
+			switch {
+			case len(least) == 0 || f < ts.frequency[i][least[0]]:
+				least = []int{l}
+			case f == ts.frequency[i][least[0]]:
+				least = append(least, l)
+			}
+		}
+		ts.Levels[i] = least[rand.Intn(len(least))]
+	}
+	ts.tabu = make([][2]int, len(ts.Levels))
+	ts.Revenue = ts.evaluate(ts.Levels)
+	ts.keepBest()
+	ts.stagnant = 0
+	ts.Diversifications++
+}
+
+// keepBest records the current prices if they are the best found, reporting whether they were
+func (ts *Search) keepBest() bool {
+	if ts.Revenue > ts.BestRevenue {
+		ts.BestPrices, ts.BestRevenue = ts.Prices(ts.Levels), ts.Revenue
+		return true
+	}
+	return false
+}
+
+// evaluate returns the revenue of the prices at levels, negative infinity if they are invalid
+func (ts *Search) evaluate(levels []int) float64 {
+	prices := ts.Prices(levels)
+	if !ts.problem.IsValid(prices) {
+		return math.Inf(-1)
+	}
+	rev, err := ts.problem.Evaluate(prices)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return rev
+}
+
+// ladders returns the ladder of each good, the ladders of opts clamped to the bounds, sorted and without repeats,
+// or Levels prices evenly spaced from each good's lower to upper bound for goods without a custom ladder
+func ladders(opts Options, bounds [][]float64) [][]float64 {
+	ladders := make([][]float64, len(bounds))
+	for i := range bounds {
+		if i < len(opts.Ladders) && len(opts.Ladders[i]) > 0 {
+			ladder := make([]float64, len(opts.Ladders[i]))
+			for l, price := range opts.Ladders[i] {
+				ladder[l] = math.Min(math.Max(price, bounds[i][0]), bounds[i][1])
+			}
+			sort.Float64s(ladder)
+			for l, price := range ladder {
+				if l == 0 || price != ladder[l-1] {
+					ladders[i] = append(ladders[i], price)
+				}
+			}
+			continue
+		}
+		levels := opts.Levels
+		if levels < 2 {
+			levels = 2
+		}
+		ladders[i] = make([]float64, levels)
+		for l := range ladders[i] {
+			ladders[i][l] = bounds[i][0] + float64(l)*(bounds[i][1]-bounds[i][0])/float64(levels-1)
+		}
+	}
+	return ladders
+}
@@ -0,0 +1,178 @@
+package tabu
+
+import (
+	"fmt"
+	"testing"
+
+	pp "github.com/aagoldingay/ci-cw-go/pricingproblem"
+)
+
+func Test_ladders(t *testing.T) {
+	bounds := [][]float64{{1, 3}, {0, 10}}
+	opts := DefaultOptions()
+	opts.Levels = 5
+	even := ladders(opts, bounds)
+	if len(even[0]) != 5 || even[0][0] != 1 || even[0][2] != 2 || even[0][4] != 3 {
+		t.Errorf("expected 5 even levels from 1 to 3, got %v", even[0])
+	}
+
+	tests := []struct {
+		name    string
+		ladders [][]float64
+		want    string
+	}{
+		{"clamped", [][]float64{{0.99, 1.49, 1.99, 9.99}, {4.99, 5.99}}, "[[1 1.49 1.99 3] [4.99 5.99]]"},
+		{"sorted without repeats", [][]float64{{2, 1.5, 2, 1.5}, {7, 3, 3}}, "[[1.5 2] [3 7]]"},
+		{"empty ladder", [][]float64{{1.5}, {}}, "[[1.5] [0 2.5 5 7.5 10]]"},
+		{"too few ladders", [][]float64{{1.5, 2.5}}, "[[1.5 2.5] [0 2.5 5 7.5 10]]"},
+	}
+	for _, tt := range tests {
+		opts.Ladders = tt.ladders
+		if got := fmt.Sprint(ladders(opts, bounds)); got != tt.want {
+			t.Errorf("%v : expected %v, got %v", tt.name, tt.want, got)
+		}
+		// every good has a level to start from and move between
+		NewSearch(opts, ramp{2}).Step()
+	}
+}
+
+func Test_tabuAttributes(t *testing.T) {
+	tests := []struct {
+		attribute          Attribute
+		reverse, continues bool // whether reversing or continuing a move up is tabu
+	}{
+		{GoodTabu, true, true},
+		{DirectionTabu, true, false},
+	}
+	for _, tt := range tests {
+		ts := &Search{opts: Options{Attribute: tt.attribute, Tenure: 3}, tabu: make([][2]int, 2)}
+		ts.makeTabu(move{0, 1})
+		if got := ts.isTabu(move{0, -1}); got != tt.reverse {
+			t.Errorf("%v : reversing tabu %v, expected %v", tt.attribute, got, tt.reverse)
+		}
+		if got := ts.isTabu(move{0, 10}); got != tt.continues {
+			t.Errorf("%v : continuing tabu %v, expected %v", tt.attribute, got, tt.continues)
+		}
+		if ts.isTabu(move{1, -1}) {
+			t.Errorf("%v : another good is tabu", tt.attribute)
+		}
+		ts.steps = 3
+		if ts.isTabu(move{0, -1}) {
+			t.Errorf("%v : still tabu after the tenure", tt.attribute)
+		}
+	}
+}
+
+// ramp earns the sum of the prices, within bounds [0, 10]
+type ramp struct {
+	goods int
+}
+
+func (r ramp) Evaluate(prices []float64) (float64, error) {
+	var rev float64
+	for _, p := range prices {
+		rev += p
+	}
+	return rev, nil
+}
+
+func (r ramp) Bounds() [][]float64 {
+	bounds := make([][]float64, r.goods)
+	for i := range bounds {
+		bounds[i] = []float64{0, 10}
+	}
+	return bounds
+}
+
+func (r ramp) IsValid(prices []float64) bool { return true }
+
+// flat earns the same revenue for any prices, within bounds [0, 10]
+type flat struct {
+	ramp
+}
+
+func (flat) Evaluate(prices []float64) (float64, error) { return 1, nil }
+
+func Test_aspiration(t *testing.T) {
+	tests := []struct {
+		aspiration  bool
+		best        float64
+		level       int // level moved to from 5, moving up being tabu
+		aspirations int
+	}{
+		{true, 5, 6, 1},   // the tabu move beats the best, so is taken
+		{false, 5, 4, 0},  // without aspiration the tabu move is never taken
+		{true, 100, 4, 0}, // the tabu move does not beat the best
+	}
+	for _, tt := range tests {
+		opts := Options{Ladders: [][]float64{{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10}}, Stride: 1, Attribute: DirectionTabu, Tenure: 5, Aspiration: tt.aspiration}
+		ts := NewSearch(opts, ramp{1})
+		ts.Levels, ts.Revenue, ts.BestRevenue = []int{5}, 5, tt.best
+		ts.tabu[0][1] = 100
+		ts.Step()
+		if ts.Levels[0] != tt.level || ts.Aspirations != tt.aspirations {
+			t.Errorf("aspiration %v, best %v : moved to %v with %v aspirations", tt.aspiration, tt.best, ts.Levels[0], ts.Aspirations)
+		}
+		// the move taken makes its reverse tabu
+		if reverse := (move{0, 5 - tt.level}); !ts.isTabu(reverse) {
+			t.Errorf("aspiration %v, best %v : reversing the move is not tabu", tt.aspiration, tt.best)
+		}
+	}
+}
+
+func Test_diversify(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Levels = 5
+	ts := NewSearch(opts, ramp{2})
+	ts.frequency = [][]int{{3, 0, 2, 2, 2}, {1, 1, 0, 5, 5}}
+	ts.tabu[0], ts.stagnant = [2]int{100, 100}, 50
+	ts.diversify()
+	if fmt.Sprint(ts.Levels) != "[1 2]" || ts.Revenue != 2.5+5 || ts.Diversifications != 1 || ts.stagnant != 0 {
+		t.Errorf("diversified to %v with revenue %v", ts.Levels, ts.Revenue)
+	}
+	if ts.isTabu(move{0, 1}) || ts.isTabu(move{0, -1}) {
+		t.Errorf("diversifying kept the tabu list %v", ts.tabu)
+	}
+
+	// a search which never improves diversifies every Patience steps, each time to the least visited levels
+	opts.Patience = 10
+	ts = NewSearch(opts, flat{ramp{3}})
+	for i := 1; i <= 50; i++ {
+		before := ts.Diversifications
+		ts.Step()
+		if ts.Diversifications == before {
+			continue
+		}
+		if i%10 != 0 {
+			t.Errorf("diversified after step %v, expected every 10", i)
+		}
+		for g, l := range ts.Levels {
+			for _, f := range ts.frequency[g] {
+				if f < ts.frequency[g][l] {
+					t.Errorf("step %v : good %v moved to level %v visited %v times, %v were visited less", i, g, l, ts.frequency[g][l], ts.frequency[g])
+				}
+			}
+		}
+	}
+	if ts.Diversifications != 5 {
+		t.Errorf("expected 5 diversifications, actual %v", ts.Diversifications)
+	}
+}
+
+func Test_improvesPricing(t *testing.T) {
+	p := pp.PricingProblem{}
+	pr := p.MakeProblem(10, 0, false)
+	for _, a := range Attributes {
+		opts := DefaultOptions()
+		opts.Attribute = a
+		ts := NewSearch(opts, pr)
+		_, initial := ts.Best()
+		for i := 0; i < 100; i++ {
+			ts.Step()
+		}
+		prices, rev := ts.Best()
+		if check, _ := pr.Evaluate(prices); rev <= initial || check != rev {
+			t.Errorf("%v tabu improved %v to %v, prices earn %v", a, initial, rev, check)
+		}
+	}
+}
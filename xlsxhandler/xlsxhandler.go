@@ -3,11 +3,14 @@ package xlsxhandler
 import (
 	"strconv"
 
+	"github.com/aagoldingay/ci-cw-go/ais"
+	"github.com/aagoldingay/ci-cw-go/ga"
+	"github.com/aagoldingay/ci-cw-go/pso"
 	"github.com/tealeg/xlsx"
 )
 
 // WriteXLSXParams writes the values of a full execution to the parameter sheets of Data.xlsx
-func WriteXLSXParams(revenue [][]float64, psoPopulation, aisPopulation, aisReplacement, aisClonesFactor int) {
+func WriteXLSXParams(revenue [][]float64, psoPopulation int, psoOptions pso.Options, aisPopulation int, aisOptions ais.Options, gaPopulation int, gaOptions ga.Options) {
 	xl, err := xlsx.OpenFile("Data.xlsx")
 	if err != nil {
 		panic(err)
@@ -17,9 +20,9 @@ func WriteXLSXParams(revenue [][]float64, psoPopulation, aisPopulation, aisRepla
 
 	psoRow := pso.AddRow()
 	psoRow.AddCell().Value = strconv.Itoa(psoPopulation)
-	psoRow.AddCell().Value = "0" // inertia add manually
-	psoRow.AddCell().Value = "0" // cognitive add manually
-	psoRow.AddCell().Value = "0" // social add manually
+	psoRow.AddCell().Value = psoOptions.Params()["inertia"] // empty under constriction
+	psoRow.AddCell().Value = strconv.FormatFloat(psoOptions.Cognitive, 'f', -1, 64)
+	psoRow.AddCell().Value = strconv.FormatFloat(psoOptions.Social, 'f', -1, 64)
 	for _, rev := range revenue[1] {
 		cell := psoRow.AddCell()
 		cell.Value = strconv.FormatFloat(rev, 'f', -1, 64) // consecutive prints of 3 tested seeds
@@ -27,14 +30,24 @@ func WriteXLSXParams(revenue [][]float64, psoPopulation, aisPopulation, aisRepla
 
 	aisRow := ais.AddRow()
 	aisRow.AddCell().Value = strconv.Itoa(aisPopulation)
-	aisRow.AddCell().Value = strconv.Itoa(aisClonesFactor)
-	aisRow.AddCell().Value = strconv.Itoa(aisReplacement)
-	aisRow.AddCell().Value = "0" // best fitness add manually
+	aisRow.AddCell().Value = strconv.Itoa(aisOptions.CloneSizeFactor)
+	aisRow.AddCell().Value = strconv.Itoa(aisOptions.Replacement)
+	aisRow.AddCell().Value = aisOptions.Params()["bestFitness"] // empty under adaptive normalisation
 	for _, rev := range revenue[2] {
 		cell := aisRow.AddCell()
 		cell.Value = strconv.FormatFloat(rev, 'f', -1, 64) // consecutive prints of 3 tested seeds
 	}
 
+	gaRow := sheet(xl, "GAParam").AddRow()
+	gaRow.AddCell().Value = strconv.Itoa(gaPopulation)
+	gaRow.AddCell().Value = gaOptions.Selection.String()
+	gaRow.AddCell().Value = gaOptions.Crossover.String()
+	gaRow.AddCell().Value = strconv.Itoa(gaOptions.Elitism)
+	for _, rev := range revenue[3] {
+		cell := gaRow.AddCell()
+		cell.Value = strconv.FormatFloat(rev, 'f', -1, 64) // consecutive prints of 3 tested seeds
+	}
+
 	err = xl.Save("Data.xlsx") // saves changes
 	if err != nil {
 		panic(err)
@@ -42,7 +55,7 @@ func WriteXLSXParams(revenue [][]float64, psoPopulation, aisPopulation, aisRepla
 }
 
 // WriteXLSXRevenues writes values of all algorithm executions to Data.xlsx
-func WriteXLSXRevenues(seeds []int64, randomRevenues, psoRevenues, aisRevenues [][]float64) {
+func WriteXLSXRevenues(seeds []int64, randomRevenues, psoRevenues, aisRevenues, gaRevenues [][]float64) {
 	xl, err := xlsx.OpenFile("Data.xlsx")
 	if err != nil {
 		panic(err)
@@ -50,6 +63,7 @@ func WriteXLSXRevenues(seeds []int64, randomRevenues, psoRevenues, aisRevenues [
 	random := xl.Sheets[2]
 	pso := xl.Sheets[3]
 	ais := xl.Sheets[4]
+	ga := sheet(xl, "GA")
 
 	// record random
 	for i := 0; i < len(seeds); i++ {
@@ -90,9 +104,34 @@ func WriteXLSXRevenues(seeds []int64, randomRevenues, psoRevenues, aisRevenues [
 		}
 	}
 
+	// record ga
+	for i := 0; i < len(seeds); i++ {
+		gaRow := ga.AddRow()
+
+		// first cell = seed
+		gaRow.AddCell().Value = strconv.FormatInt(seeds[i], 10)
+
+		// next 100 for steps
+		for j := 0; j < len(gaRevenues[i]); j++ {
+			gaRow.AddCell().Value = strconv.FormatFloat(gaRevenues[i][j], 'f', -1, 64)
+		}
+	}
+
 	// save file and complete
 	err = xl.Save("Data.xlsx")
 	if err != nil {
 		panic(err)
 	}
 }
+
+// sheet returns the sheet of Data.xlsx called name, adding it if missing
+func sheet(xl *xlsx.File, name string) *xlsx.Sheet {
+	if s, ok := xl.Sheet[name]; ok {
+		return s
+	}
+	s, err := xl.AddSheet(name)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}